@@ -0,0 +1,527 @@
+package gdi
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// ConfigSource 配置来源，返回扁平化的 key/value，嵌套的 key 使用.分隔，如 db.dsn
+type ConfigSource interface {
+	Load() (map[string]string, error)
+}
+
+// ConfigSourceFunc 函数形式的配置来源
+type ConfigSourceFunc func() (map[string]string, error)
+
+// Load 加载配置
+func (f ConfigSourceFunc) Load() (map[string]string, error) {
+	return f()
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// AddConfigSource 添加配置来源，后添加的来源优先级更高
+func AddConfigSource(sources ...ConfigSource) error {
+	return globalGDI.AddConfigSource(sources...)
+}
+
+// GetConfig 获取配置
+func GetConfig(key string) (string, bool) {
+	return globalGDI.GetConfig(key)
+}
+
+// SetConfig 设置配置
+func SetConfig(key string, value string) {
+	globalGDI.SetConfig(key, value)
+}
+
+// AddConfigSource 添加配置来源，后添加的来源优先级更高
+// Example：gdi.AddConfigSource(gdi.FileSource("config.yaml"), gdi.DotEnvSource(".env"), gdi.EnvSource("APP_"), gdi.FlagSource(nil))
+func (gdi *GDIPool) AddConfigSource(sources ...ConfigSource) error {
+	for _, source := range sources {
+		kvs, err := source.Load()
+		if err != nil {
+			return err
+		}
+		for k, v := range kvs {
+			gdi.SetConfig(k, v)
+		}
+	}
+	return nil
+}
+
+// GetConfig 获取配置，key 不区分大小写，且.-_视为相同，即 db.max_conns 与环境变量 DB_MAX_CONNS 相同
+func (gdi *GDIPool) GetConfig(key string) (string, bool) {
+	gdi.ttvLocker.RLock()
+	value, ok := gdi.config[normalizeConfigKey(key)]
+	gdi.ttvLocker.RUnlock()
+	if !ok && gdi.parent != nil {
+		return gdi.parent.GetConfig(key)
+	}
+	return value, ok
+}
+
+// SetConfig 设置配置
+func (gdi *GDIPool) SetConfig(key string, value string) {
+	gdi.ttvLocker.Lock()
+	defer gdi.ttvLocker.Unlock()
+	gdi.config[normalizeConfigKey(key)] = value
+}
+
+func normalizeConfigKey(key string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(key)))
+}
+
+// EnvSource 从环境变量加载配置，只加载以 prefix 开头的变量并去掉前缀，如 APP_DB_DSN -> db.dsn
+func EnvSource(prefix string) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]string, error) {
+		kvs := make(map[string]string)
+		for _, env := range os.Environ() {
+			kv := strings.SplitN(env, "=", 2)
+			if len(kv) != 2 || !strings.HasPrefix(kv[0], prefix) {
+				continue
+			}
+			kvs[strings.TrimPrefix(kv[0], prefix)] = kv[1]
+		}
+		return kvs, nil
+	})
+}
+
+// FlagSource 从命令行参数加载配置，只加载显式设置的参数，fs 为 nil 时使用 flag.CommandLine
+func FlagSource(fs *flag.FlagSet) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]string, error) {
+		if fs == nil {
+			fs = flag.CommandLine
+		}
+		kvs := make(map[string]string)
+		fs.Visit(func(f *flag.Flag) {
+			kvs[f.Name] = f.Value.String()
+		})
+		return kvs, nil
+	})
+}
+
+// MapSource 从 map 加载配置
+func MapSource(kvs map[string]string) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]string, error) {
+		return kvs, nil
+	})
+}
+
+// FileSource 根据文件扩展名加载配置文件，支持 .json .yaml .yml .toml .env
+func FileSource(path string) ConfigSource {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSONSource(path)
+	case ".yaml", ".yml":
+		return YAMLSource(path)
+	case ".toml":
+		return TOMLSource(path)
+	default:
+		return DotEnvSource(path)
+	}
+}
+
+// DotEnvSource 从 .env 文件加载配置，格式为 KEY=VALUE
+func DotEnvSource(path string) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]string, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		kvs := make(map[string]string)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			kv := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("(ERROR) %v invalid line: %v", path, line)
+			}
+			kvs[strings.TrimSpace(kv[0])] = unquote(kv[1])
+		}
+		return kvs, scanner.Err()
+	})
+}
+
+// JSONSource 从 JSON 文件加载配置，嵌套对象展开为 a.b，数组展开为逗号分隔的值
+func JSONSource(path string) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]string, error) {
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var data interface{}
+		if err = json.Unmarshal(bs, &data); err != nil {
+			return nil, fmt.Errorf("(ERROR) parse %v fail %v", path, err)
+		}
+		kvs := make(map[string]string)
+		flattenConfig("", data, kvs)
+		return kvs, nil
+	})
+}
+
+func flattenConfig(prefix string, data interface{}, kvs map[string]string) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch d := data.(type) {
+	case map[string]interface{}:
+		for k, v := range d {
+			flattenConfig(join(k), v, kvs)
+		}
+	case []interface{}:
+		var items []string
+		for i, v := range d {
+			flattenConfig(join(strconv.Itoa(i)), v, kvs)
+			items = append(items, fmt.Sprintf("%v", v))
+		}
+		kvs[prefix] = strings.Join(items, ",")
+	case nil:
+		kvs[prefix] = ""
+	case float64:
+		kvs[prefix] = strconv.FormatFloat(d, 'f', -1, 64)
+	default:
+		kvs[prefix] = fmt.Sprintf("%v", d)
+	}
+}
+
+// YAMLSource 从 YAML 文件加载配置，支持常用的子集：嵌套对象、列表(- item 或 [a, b])、注释，
+// 不支持的语法(对象列表、多行字符串、{a: b}行内对象)返回带行号的错误
+func YAMLSource(path string) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]string, error) {
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseYAML(string(bs))
+	})
+}
+
+func parseYAML(content string) (map[string]string, error) {
+	type level struct {
+		indent int
+		key    string
+	}
+	kvs := make(map[string]string)
+	var stack []level
+	for n, raw := range strings.Split(content, "\n") {
+		line := strings.TrimRight(stripComment(raw), " \t\r")
+		if strings.TrimSpace(line) == "" || strings.TrimSpace(line) == "---" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		line = strings.TrimSpace(line)
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent && !(strings.HasPrefix(line, "- ") && stack[len(stack)-1].indent == indent) {
+			stack = stack[:len(stack)-1]
+		}
+		var keys []string
+		for _, l := range stack {
+			keys = append(keys, l.key)
+		}
+		if strings.HasPrefix(line, "- ") || line == "-" {
+			if len(keys) == 0 {
+				return nil, fmt.Errorf("(ERROR) yaml line %v: list without key", n+1)
+			}
+			item := strings.TrimSpace(strings.TrimPrefix(line, "-"))
+			if hasMappingColon(item) {
+				return nil, fmt.Errorf("(ERROR) yaml line %v: list of maps not supported %v", n+1, line)
+			}
+			if err := checkYAMLValue(item); err != nil {
+				return nil, fmt.Errorf("(ERROR) yaml line %v: %v", n+1, err)
+			}
+			key := strings.Join(keys, ".")
+			if kvs[key] == "" {
+				kvs[key] = unquote(strings.TrimPrefix(line, "-"))
+			} else {
+				kvs[key] = kvs[key] + "," + unquote(strings.TrimPrefix(line, "-"))
+			}
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("(ERROR) yaml line %v: invalid line %v", n+1, line)
+		}
+		key := unquote(kv[0])
+		value := strings.TrimSpace(kv[1])
+		if value == "" {
+			stack = append(stack, level{indent: indent, key: key})
+			continue
+		}
+		if err := checkYAMLValue(value); err != nil {
+			return nil, fmt.Errorf("(ERROR) yaml line %v: %v", n+1, err)
+		}
+		kvs[strings.Join(append(keys, key), ".")] = parseInlineList(value)
+	}
+	return kvs, nil
+}
+
+// checkYAMLValue 检查不支持的 YAML 值：多行字符串(| >)与行内对象
+func checkYAMLValue(value string) error {
+	switch {
+	case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+		return fmt.Errorf("multi-line string not supported %v", value)
+	case strings.HasPrefix(value, "{"):
+		return fmt.Errorf("inline map not supported %v", value)
+	case strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]"):
+		return fmt.Errorf("multi-line list not supported %v", value)
+	}
+	return nil
+}
+
+// hasMappingColon 判断引号外是否存在 key: value 形式的冒号
+func hasMappingColon(s string) bool {
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == ':' && (i == len(s)-1 || s[i+1] == ' ' || s[i+1] == '\t'):
+			return true
+		}
+	}
+	return false
+}
+
+// TOMLSource 从 TOML 文件加载配置，支持常用的子集：[section]、key = value、数组、注释，
+// 不支持的语法([[table]]、多行字符串、多行数组、{a = b}行内表)返回带行号的错误
+func TOMLSource(path string) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]string, error) {
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseTOML(string(bs))
+	})
+}
+
+func parseTOML(content string) (map[string]string, error) {
+	kvs := make(map[string]string)
+	section := ""
+	for n, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(stripComment(raw))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[[") {
+			return nil, fmt.Errorf("(ERROR) toml line %v: array of tables not supported %v", n+1, line)
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("(ERROR) toml line %v: invalid line %v", n+1, line)
+		}
+		key := unquote(kv[0])
+		if section != "" {
+			key = section + "." + key
+		}
+		value := strings.TrimSpace(kv[1])
+		switch {
+		case strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, "'''"):
+			return nil, fmt.Errorf("(ERROR) toml line %v: multi-line string not supported %v", n+1, line)
+		case strings.HasPrefix(value, "{"):
+			return nil, fmt.Errorf("(ERROR) toml line %v: inline table not supported %v", n+1, line)
+		case strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]"):
+			return nil, fmt.Errorf("(ERROR) toml line %v: multi-line array not supported %v", n+1, line)
+		}
+		kvs[key] = parseInlineList(value)
+	}
+	return kvs, nil
+}
+
+// stripComment 去掉#之后的注释(引号内的#除外)
+func stripComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseInlineList 将 [a, "b"] 转换为 a,b，其它值去掉引号
+func parseInlineList(value string) string {
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		var items []string
+		for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+			if item = unquote(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return strings.Join(items, ",")
+	}
+	return unquote(value)
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// injectConfig 按 inject:"config:db.dsn;default:localhost" 将配置注入到属性中，结构体属性按属性名(蛇形)展开为 db.xxx
+func (gdi *GDIPool) injectConfig(field reflect.Value, f reflect.StructField, key string) error {
+	def, hasDefault := gdi.getTagAttr(f, "default")
+	if field.Kind() == reflect.Struct || field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct && !isConfigScalar(field.Type()) {
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+		return gdi.injectConfigStruct(field, key)
+	}
+	value, ok := gdi.GetConfig(key)
+	if !ok {
+		if !hasDefault {
+			return fmt.Errorf("config key:%v not found", key)
+		}
+		value = def
+	}
+	if err := setConfigValue(field, value); err != nil {
+		return fmt.Errorf("config key:%v value:%v %v", key, value, err)
+	}
+	return nil
+}
+
+// injectConfigStruct 将 prefix 下的配置注入到结构体的每个属性中，属性上也可以使用 inject:"config:xxx" 指定 key
+func (gdi *GDIPool) injectConfigStruct(v reflect.Value, prefix string) error {
+	var errs MultiError
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		field := v.Field(i)
+		if gdi.getInjectOption(f) == injectSkip {
+			continue
+		}
+		if !field.CanSet() {
+			field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+		}
+		key, ok := gdi.getTagAttr(f, "config")
+		if !ok || key == "" {
+			key = prefix + "." + gdi.ConvertToSnakeCase(f.Name)
+		}
+		_, hasDefault := gdi.getTagAttr(f, "default")
+		if _, found := gdi.GetConfig(key); !found && !hasDefault && gdi.getInjectOption(f) != injectRequired {
+			if field.Kind() != reflect.Struct && !(field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct) {
+				continue
+			}
+		}
+		if err := gdi.injectConfig(field, f, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func isConfigScalar(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()) ||
+		t.Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
+
+// setConfigValue 将字符串转换为属性的类型：字符串、布尔、整数、浮点数、time.Duration、切片及实现了 encoding.TextUnmarshaler 的类型
+func setConfigValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		if u, ok := field.Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(value))
+		}
+		return setConfigValue(field.Elem(), value)
+	}
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(value))
+		}
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			n, e := strconv.ParseInt(value, 10, 64)
+			if e != nil {
+				return err
+			}
+			d = time.Duration(n)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setConfigValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	default:
+		return fmt.Errorf("type %v not support", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package gdi
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type cfgDB struct {
+	DSN      string
+	MaxConns int
+	Timeout  time.Duration
+}
+
+type cfgApp struct {
+	Name    string        `inject:"config:app.name"`
+	Port    *int          `inject:"config:server.port"`
+	Debug   bool          `inject:"config:app.debug;default:true"`
+	Hosts   []string      `inject:"config:server.hosts"`
+	Retry   time.Duration `inject:"config:app.retry;default:1s"`
+	DB      cfgDB         `inject:"config:db"`
+	Level   string        `inject:"config:log.level"`
+	Missing string        `inject:"config:not.exists"`
+}
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	fn := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestConfigInject(t *testing.T) {
+	dir := t.TempDir()
+	yaml := writeConfigFile(t, dir, "app.yaml", `
+app:
+  name: "demo" # comment
+server:
+  port: 8080
+  hosts:
+    - a.com
+    - b.com
+`)
+	toml := writeConfigFile(t, dir, "db.toml", `
+[db]
+dsn = "root@tcp(localhost:3306)/test"
+max_conns = 10
+`)
+	json := writeConfigFile(t, dir, "db.json", `{"db":{"timeout":"3s"}}`)
+	env := writeConfigFile(t, dir, ".env", "# comment\nexport LOG_LEVEL='warn'\n")
+	os.Setenv("GDI_TEST_SERVER_PORT", "9090")
+	defer os.Unsetenv("GDI_TEST_SERVER_PORT")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("app.name", "", "")
+	fs.Parse([]string{"-app.name=flag"})
+
+	gp := NewGDIPool()
+	gp.Debug(false)
+	err := gp.AddConfigSource(FileSource(yaml), FileSource(toml), FileSource(json), FileSource(env), EnvSource("GDI_TEST_"), FlagSource(fs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gp.Register(&cfgApp{})
+	err = gp.InitE()
+	var ie *InjectError
+	if !errors.As(err, &ie) || ie.Field != "Missing" {
+		t.Fatalf("expect missing config key error got %v", err)
+	}
+	app := MustResolve[*cfgApp](gp)
+	if app.Name != "flag" || *app.Port != 9090 || !app.Debug || app.Retry != time.Second || app.Level != "warn" {
+		t.Fatalf("unexpected config %+v", app)
+	}
+	if len(app.Hosts) != 2 || app.Hosts[1] != "b.com" {
+		t.Fatalf("unexpected hosts %v", app.Hosts)
+	}
+	if app.DB.DSN != "root@tcp(localhost:3306)/test" || app.DB.MaxConns != 10 || app.DB.Timeout != 3*time.Second {
+		t.Fatalf("unexpected db config %+v", app.DB)
+	}
+}
+
+func TestConfigParseUnsupported(t *testing.T) {
+	cases := []struct {
+		parse   func(string) (map[string]string, error)
+		content string
+		expect  string
+	}{
+		{parseYAML, "servers:\n  - host: a\n    port: 1\n", "yaml line 2: list of maps not supported"},
+		{parseYAML, "app:\n  desc: |\n    line1\n    line2\n", "yaml line 2: multi-line string not supported"},
+		{parseYAML, "db: {dsn: x}\n", "yaml line 1: inline map not supported"},
+		{parseTOML, "[db]\nopts = { a = 1 }\n", "toml line 2: inline table not supported"},
+		{parseTOML, "desc = \"\"\"\nline1\n\"\"\"\n", "toml line 1: multi-line string not supported"},
+		{parseTOML, "[[servers]]\nhost = \"a\"\n", "toml line 1: array of tables not supported"},
+		{parseTOML, "hosts = [\n  \"a\",\n]\n", "toml line 1: multi-line array not supported"},
+	}
+	for _, c := range cases {
+		if _, err := c.parse(c.content); err == nil || !strings.Contains(err.Error(), c.expect) {
+			t.Fatalf("expect error %q for %q, got %v", c.expect, c.content, err)
+		}
+	}
+	kvs, err := parseYAML("hosts:\n  - \"a:1\"\n  - http://b\n")
+	if err != nil || kvs["hosts"] != "a:1,http://b" {
+		t.Fatalf("quoted colon and url should be plain list items, got %v %v", kvs, err)
+	}
+}
@@ -0,0 +1,368 @@
+package gdi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// creatorNode 构造函数依赖图中的节点
+type creatorNode struct {
+	outType    reflect.Type
+	creator    interface{}
+	deps       []reflect.Type // 依赖的其它构造函数的返回类型
+	dependents []*creatorNode
+	inDegree   int
+}
+
+// creatorGraph 根据构造函数的参数类型构建依赖图，返回还未创建的构造函数(按类型名排序)
+func (gdi *GDIPool) creatorGraph() ([]*creatorNode, map[reflect.Type]*creatorNode) {
+	gdi.creatorLocker.RLock()
+	nodes := make(map[reflect.Type]*creatorNode)
+	for outType, creator := range gdi.creator {
+		if _, ok := gdi.get(outType); ok || gdi.invoked[outType] {
+			continue
+		}
+		nodes[outType] = &creatorNode{outType: outType, creator: creator}
+	}
+	gdi.creatorLocker.RUnlock()
+	var sorted []*creatorNode
+	for _, n := range nodes {
+		sorted = append(sorted, n)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].outType.String() < sorted[j].outType.String()
+	})
+	for _, n := range sorted {
+		funcType := reflect.TypeOf(n.creator)
+		for i := 0; i < funcType.NumIn(); i++ {
+			in := gdi.paramType(funcType.In(i), nodes)
+			if _, lazy := lazyTargetOf(in); lazy {
+				continue
+			}
+			if dep, ok := nodes[in]; ok && dep != n {
+				n.deps = append(n.deps, in)
+				n.inDegree++
+				dep.dependents = append(dep.dependents, n)
+			}
+		}
+	}
+	return sorted, nodes
+}
+
+// paramType 构造函数的参数为接口且容器中没有该接口时，使用 Bind 设定的默认实现，没有设定时使用唯一的实现
+// (已保存的对象或 nodes 中构造函数的返回类型)，找不到或有多个实现时返回原来的类型
+func (gdi *GDIPool) paramType(in reflect.Type, nodes map[reflect.Type]*creatorNode) reflect.Type {
+	if in.Kind() != reflect.Interface || in == contextType {
+		return in
+	}
+	if _, ok := gdi.get(in); ok {
+		return in
+	}
+	if t, ok := gdi.getQualifiedType(in, ""); ok {
+		return t
+	}
+	impls := make(map[reflect.Type]bool)
+	for t := range gdi.all() {
+		if t.Implements(in) {
+			impls[t] = true
+		}
+	}
+	for t := range nodes {
+		if t.Implements(in) {
+			impls[t] = true
+		}
+	}
+	if len(impls) == 1 {
+		for t := range impls {
+			return t
+		}
+	}
+	return in
+}
+
+// resolveParam 获取构造函数的参数，接口优先使用装饰后的对象，其次按 paramType 确定的实现获取
+func (gdi *GDIPool) resolveParam(in reflect.Type) (reflect.Value, bool) {
+	if in.Kind() == reflect.Interface && in != contextType {
+		if value, ok := gdi.getDecorated(in); ok {
+			return value, true
+		}
+	}
+	if value, ok := gdi.resolve(in); ok {
+		return value, true
+	}
+	if t := gdi.paramType(in, nil); t != in {
+		return gdi.resolve(t)
+	}
+	return reflect.Value{}, false
+}
+
+// missingParams 返回构造函数中既不在容器中、也没有构造函数可以提供的参数类型
+func (gdi *GDIPool) missingParams(n *creatorNode, nodes map[reflect.Type]*creatorNode) []reflect.Type {
+	var missing []reflect.Type
+	funcType := reflect.TypeOf(n.creator)
+	for i := 0; i < funcType.NumIn(); i++ {
+		in := gdi.paramType(funcType.In(i), nodes)
+		if _, ok := nodes[in]; ok && in != n.outType {
+			continue
+		}
+		if _, lazy := lazyTargetOf(in); lazy {
+			continue
+		}
+		if _, ok := gdi.get(in); ok || gdi.isPrototype(in) || in == contextType {
+			continue
+		}
+		missing = append(missing, in)
+	}
+	return missing
+}
+
+// initCreators 按构造函数参数的依赖关系进行拓扑排序，依次调用构造函数，返回所有无法创建的错误
+func (gdi *GDIPool) initCreators(ctx context.Context) []error {
+	sorted, nodes := gdi.creatorGraph()
+	var errs []error
+	failed := make(map[reflect.Type]error)
+	var queue []*creatorNode
+	for _, n := range sorted {
+		if missing := gdi.missingParams(n, nodes); len(missing) > 0 {
+			failed[n.outType] = fmt.Errorf("missing parameter type %v", missing)
+		}
+		if n.inDegree == 0 {
+			queue = append(queue, n)
+		}
+	}
+	done := 0
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		done++
+		if err, ok := failed[n.outType]; !ok {
+			if err = gdi.invokeCreator(ctx, n.outType, n.creator); err != nil {
+				failed[n.outType] = err
+			}
+		}
+		for _, d := range n.dependents {
+			if _, ok := failed[n.outType]; ok {
+				if _, ok := failed[d.outType]; !ok {
+					failed[d.outType] = fmt.Errorf("depends on unresolved parameter type %v", n.outType)
+				}
+			}
+			d.inDegree--
+			if d.inDegree == 0 {
+				queue = append(queue, d)
+			}
+		}
+	}
+	for _, n := range sorted {
+		if err, ok := failed[n.outType]; ok {
+			errs = append(errs, &CreateError{Type: n.outType.String(), Creator: reflect.TypeOf(n.creator).String(), Err: err})
+		}
+	}
+	if done < len(sorted) {
+		errs = append(errs, creatorCycleErrors(sorted, nodes)...)
+	}
+	return errs
+}
+
+// creatorCycleErrors 拓扑排序结束后入度仍大于0的构造函数存在循环依赖
+func creatorCycleErrors(sorted []*creatorNode, nodes map[reflect.Type]*creatorNode) []error {
+	var errs []error
+	inCycle := make(map[*creatorNode]bool)
+	for _, n := range sorted {
+		if n.inDegree == 0 || inCycle[n] {
+			continue
+		}
+		cycle := creatorCycle(n, nodes)
+		if inCycle[cycle[0]] {
+			continue
+		}
+		var path []string
+		for _, c := range cycle {
+			inCycle[c] = true
+			path = append(path, c.outType.String())
+		}
+		errs = append(errs, &CreateError{Type: cycle[0].outType.String(), Creator: reflect.TypeOf(cycle[0].creator).String(), Err: &CycleError{Path: path}})
+	}
+	for _, n := range sorted {
+		if n.inDegree > 0 && !inCycle[n] {
+			errs = append(errs, &CreateError{Type: n.outType.String(), Creator: reflect.TypeOf(n.creator).String(), Err: fmt.Errorf("depends on circular dependency")})
+		}
+	}
+	return errs
+}
+
+// invokeCreator 从容器中获取参数并调用构造函数，将返回值按类型或名称保存到容器中
+func (gdi *GDIPool) invokeCreator(ctx context.Context, outType reflect.Type, creator interface{}) error {
+	values, err := gdi.callCreator(ctx, outType, creator)
+	if err != nil {
+		return err
+	}
+	gdi.storeCreated(outType, creator, values)
+	return nil
+}
+
+// creatorArgField 构造函数参数作为依赖时的名称前缀，如 arg#0 为第一个参数
+const creatorArgField = "arg#"
+
+// callCreator 从容器中获取参数并调用构造函数，容器中没有 context.Context 时传入 ctx
+func (gdi *GDIPool) callCreator(ctx context.Context, outType reflect.Type, creator interface{}) ([]reflect.Value, error) {
+	funcType := reflect.TypeOf(creator)
+	var args []reflect.Value
+	for n := 0; n < funcType.NumIn(); n++ {
+		arg, ok := gdi.lazyArg(funcType.In(n), creator)
+		if !ok {
+			arg, ok = gdi.resolveParam(funcType.In(n))
+		}
+		if !ok && funcType.In(n) == contextType {
+			arg, ok = reflect.ValueOf(&ctx).Elem(), true
+		}
+		if !ok {
+			return nil, fmt.Errorf("missing parameter type %v", funcType.In(n))
+		}
+		args = append(args, arg)
+	}
+	start := time.Now()
+	values := reflect.ValueOf(creator).Call(args)
+	if len(values) > 1 && values[1].Type().Implements(reflect.TypeOf((*error)(nil)).Elem()) && !values[1].IsNil() {
+		gdi.emitCreate(CreateEvent{Type: outType, Creator: funcType, Duration: time.Since(start), Err: values[1].Interface().(error)})
+		return nil, values[1].Interface().(error)
+	}
+	gdi.emitCreate(CreateEvent{Type: outType, Creator: funcType, Duration: time.Since(start)})
+	for n, arg := range args { // 构造函数的参数同样是返回对象的依赖，用于 AfterInject 及 Shutdown 的顺序
+		if funcType.In(n) != contextType {
+			gdi.addDependency(values[0], fmt.Sprintf("%v%v", creatorArgField, n), arg)
+		}
+	}
+	return values, nil
+}
+
+// storeCreated 将构造函数的返回值按类型或名称保存到容器中
+func (gdi *GDIPool) storeCreated(outType reflect.Type, creator interface{}, values []reflect.Value) {
+	funcType := reflect.TypeOf(creator)
+	gdi.creatorLocker.Lock()
+	gdi.invoked[outType] = true
+	gdi.creatorLocker.Unlock()
+	gdi.ttvLocker.Lock()
+	gdi.markProvenance(values[0], ProvenanceConstructor)
+	if len(values) > 1 && values[1].Kind() == reflect.String {
+		gdi.namesToValues[values[1].Interface().(string)] = values[0]
+	} else {
+		gdi.typeToValues[outType] = values[0]
+	}
+	gdi.ttvLocker.Unlock()
+	if len(values) > 1 && values[1].Kind() == reflect.String {
+		gdi.emitRegister(RegisterEvent{Type: outType, Name: values[1].Interface().(string)})
+	} else {
+		gdi.emitRegister(RegisterEvent{Type: outType})
+	}
+	gdi.log(fmt.Sprintf("inject type %v over by %v success", outType, funcType), "event", eventCreate, "type", outType.String(), "creator", funcType.String())
+}
+
+// initCreatorsParallel 按依赖关系并发调用构造函数，没有依赖关系的构造函数同时执行，
+// 缺少参数或存在循环依赖时不调用任何构造函数，第一个构造函数失败后取消其余的构造函数，ctx 结束时不再等待正在执行的构造函数
+func (gdi *GDIPool) initCreatorsParallel(ctx context.Context) []error {
+	sorted, nodes := gdi.creatorGraph()
+	var errs []error
+	for _, n := range sorted {
+		if missing := gdi.missingParams(n, nodes); len(missing) > 0 {
+			errs = append(errs, &CreateError{Type: n.outType.String(), Creator: reflect.TypeOf(n.creator).String(), Err: fmt.Errorf("missing parameter type %v", missing)})
+		}
+	}
+	if cycleErrs := creatorCycleErrors(creatorDryRun(sorted), nodes); len(cycleErrs) > 0 || len(errs) > 0 {
+		return append(errs, cycleErrs...)
+	}
+	sorted, _ = gdi.creatorGraph()
+
+	type result struct {
+		n      *creatorNode
+		values []reflect.Value
+		err    error
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, len(sorted)) // 放弃等待的构造函数返回时不会阻塞
+	failed := make(map[*creatorNode]error)
+	finished := make(map[*creatorNode]bool)
+	var ready []*creatorNode
+	for _, n := range sorted {
+		if n.inDegree == 0 {
+			ready = append(ready, n)
+		}
+	}
+	var stopped error
+	running := 0
+loop:
+	for {
+		for stopped == nil && len(ready) > 0 {
+			n := ready[0]
+			ready = ready[1:]
+			running++
+			go func(n *creatorNode) {
+				defer func() { // 构造函数 panic 时与返回错误一样处理，取消依赖它的构造函数
+					if err := recover(); err != nil {
+						results <- result{n: n, err: fmt.Errorf("panic: %v", err)}
+					}
+				}()
+				values, err := gdi.callCreator(runCtx, n.outType, n.creator)
+				results <- result{n: n, values: values, err: err}
+			}(n)
+		}
+		if running == 0 {
+			break
+		}
+		select {
+		case r := <-results:
+			running--
+			finished[r.n] = true
+			if r.err != nil {
+				failed[r.n] = r.err
+				if stopped == nil {
+					stopped = fmt.Errorf("canceled because %v failed", r.n.outType)
+					cancel()
+				}
+				continue
+			}
+			gdi.storeCreated(r.n.outType, r.n.creator, r.values)
+			for _, d := range r.n.dependents {
+				d.inDegree--
+				if d.inDegree == 0 {
+					ready = append(ready, d)
+				}
+			}
+		case <-ctx.Done():
+			stopped = ctx.Err()
+			break loop
+		}
+	}
+	for _, n := range sorted {
+		if err, ok := failed[n]; ok {
+			errs = append(errs, &CreateError{Type: n.outType.String(), Creator: reflect.TypeOf(n.creator).String(), Err: err})
+		} else if !finished[n] {
+			errs = append(errs, &CreateError{Type: n.outType.String(), Creator: reflect.TypeOf(n.creator).String(), Err: stopped})
+		}
+	}
+	return errs
+}
+
+// creatorDryRun 不调用构造函数进行拓扑排序，返回的节点中入度仍大于0的存在循环依赖
+func creatorDryRun(sorted []*creatorNode) []*creatorNode {
+	var queue []*creatorNode
+	for _, n := range sorted {
+		if n.inDegree == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, d := range n.dependents {
+			d.inDegree--
+			if d.inDegree == 0 {
+				queue = append(queue, d)
+			}
+		}
+	}
+	return sorted
+}
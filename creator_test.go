@@ -0,0 +1,204 @@
+package gdi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type ctorA struct{ B *ctorB }
+type ctorB struct{ C *ctorC }
+type ctorC struct{ Name string }
+type ctorMissing struct{}
+type ctorX struct{}
+
+func TestInitCreatorsTopological(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.AutoCreate(false)
+	var calls []string
+	gp.Register(func(b *ctorB) *ctorA {
+		calls = append(calls, "a")
+		return &ctorA{B: b}
+	}, func(c *ctorC) (*ctorB, error) {
+		calls = append(calls, "b")
+		return &ctorB{C: c}, nil
+	}, func(name *string) *ctorC {
+		calls = append(calls, "c")
+		return &ctorC{Name: *name}
+	}, func() *string {
+		name := "c"
+		return &name
+	})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(calls, "") != "cba" {
+		t.Fatalf("unexpected call order %v", calls)
+	}
+	var a *ctorA
+	if gp.Get(a).(*ctorA).B.C.Name != "c" {
+		t.Fatal("constructor chain should be resolved")
+	}
+}
+
+func TestInitCreatorsMissing(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.AutoCreate(false)
+	gp.Register(func(m *ctorMissing) *ctorX {
+		return &ctorX{}
+	})
+	err := gp.InitE()
+	var ce *CreateError
+	if !errors.As(err, &ce) || !strings.Contains(ce.Error(), "*gdi.ctorMissing") {
+		t.Fatalf("expect missing parameter error got %v", err)
+	}
+}
+
+type parDB struct{ Name string }
+type parCache struct{ Name string }
+type parService struct {
+	DB    *parDB
+	Cache *parCache
+}
+type parSlow struct{}
+type parKey struct{}
+
+func TestInitContextParallel(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func(ctx context.Context) (*parDB, error) {
+		time.Sleep(100 * time.Millisecond)
+		return &parDB{Name: ctx.Value(parKey{}).(string)}, nil
+	}, func(ctx context.Context) (*parCache, error) {
+		time.Sleep(100 * time.Millisecond)
+		return &parCache{Name: "cache"}, nil
+	}, func(db *parDB, cache *parCache) *parService {
+		return &parService{DB: db, Cache: cache}
+	})
+	start := time.Now()
+	ctx := context.WithValue(context.Background(), parKey{}, "db")
+	if err := gp.InitContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if cost := time.Since(start); cost > 180*time.Millisecond {
+		t.Fatalf("independent constructors should run concurrently, cost %v", cost)
+	}
+	svc := gp.Get(&parService{}).(*parService)
+	if svc.DB == nil || svc.DB.Name != "db" || svc.Cache == nil {
+		t.Fatalf("unexpected service %+v", svc)
+	}
+}
+
+func TestInitContextDeadline(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func(ctx context.Context) (*parSlow, error) {
+		time.Sleep(time.Second)
+		return &parSlow{}, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := gp.InitContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("InitContext should return when ctx is done")
+	}
+	if _, ok := gp.GetWithCheck(&parSlow{}); ok {
+		t.Fatal("constructor finished after deadline should be dropped")
+	}
+}
+
+func TestInitContextCancelOnFailure(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	var canceled bool
+	gp.Register(func(ctx context.Context) (*parDB, error) {
+		return nil, errors.New("connect refused")
+	}, func(ctx context.Context) (*parCache, error) {
+		select {
+		case <-ctx.Done():
+			canceled = true
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return &parCache{}, nil
+		}
+	}, func(db *parDB, cache *parCache) *parService {
+		t.Error("constructor depends on failed one should not be called")
+		return &parService{}
+	})
+	err := gp.InitContext(context.Background())
+	var me MultiError
+	if !errors.As(err, &me) || len(me) != 3 || !canceled {
+		t.Fatalf("expect all constructors failed or canceled, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "connect refused") || !strings.Contains(err.Error(), "canceled because *gdi.parDB failed") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	gp = NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func(db *parDB, m *ctorMissing) *parService {
+		t.Error("constructor should not be called when parameter is missing")
+		return &parService{}
+	}, func(ctx context.Context) *parCache {
+		t.Error("no constructor should be called when parameter is missing")
+		return &parCache{}
+	})
+	if err := gp.InitContext(context.Background()); err == nil || !strings.Contains(err.Error(), "missing parameter") {
+		t.Fatalf("expect missing parameter, got %v", err)
+	}
+}
+
+func TestInitContextPanic(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func(ctx context.Context) (*parDB, error) {
+		panic("boom in ctor")
+	}, func(db *parDB) *parService {
+		t.Error("constructor depends on panicked one should not be called")
+		return &parService{}
+	})
+	err := gp.InitContext(context.Background())
+	var me MultiError
+	if !errors.As(err, &me) || len(me) != 2 {
+		t.Fatalf("expect constructors failed or canceled, got %v", err)
+	}
+	var ce *CreateError
+	if !errors.As(me[0], &ce) || ce.Type != "*gdi.parDB" || !strings.Contains(ce.Err.Error(), "boom in ctor") {
+		t.Fatalf("panic should be reported as CreateError, got %v", me[0])
+	}
+	if !strings.Contains(me[1].Error(), "canceled because *gdi.parDB failed") {
+		t.Fatalf("dependent should be canceled, got %v", me[1])
+	}
+}
+
+type ctorLate struct{}
+
+func TestZeroArgCreatorCalledInInit(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	called := false
+	gp.Register(func() *ctorLate {
+		called = true
+		return &ctorLate{}
+	}, func() (*ctorX, error) {
+		return nil, errors.New("load config fail")
+	})
+	if called {
+		t.Fatal("constructor should not be called when registering")
+	}
+	err := gp.InitE()
+	if !called || err == nil || !strings.Contains(err.Error(), "load config fail") {
+		t.Fatalf("constructors should be called in Init, got %v", err)
+	}
+	if _, ok := gp.GetWithCheck(&ctorLate{}); !ok {
+		t.Fatal("zero-arg constructor result should be stored")
+	}
+}
@@ -0,0 +1,130 @@
+package gdi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CycleError 循环依赖，Path 形如 *AA.B -> *BB.D -> *AA
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("circular dependency %v", strings.Join(e.Path, " -> "))
+}
+
+// Strict 严格模式，Init 时检测属性注入的循环依赖并返回错误
+func Strict(strict bool) {
+	globalGDI.Strict(strict)
+}
+
+// AllowCycle 允许经过某个属性的循环依赖(严格模式下使用) Example：gdi.AllowCycle((*EE)(nil), "A")
+func AllowCycle(ptr interface{}, fieldName string) {
+	globalGDI.AllowCycle(ptr, fieldName)
+}
+
+// Strict 严格模式，Init 时检测属性注入的循环依赖并返回错误
+func (gdi *GDIPool) Strict(strict bool) {
+	gdi.strict = strict
+}
+
+// AllowCycle 允许经过某个属性的循环依赖(严格模式下使用) Example：gdi.AllowCycle((*EE)(nil), "A")
+func (gdi *GDIPool) AllowCycle(ptr interface{}, fieldName string) {
+	gdi.creatorLocker.Lock()
+	defer gdi.creatorLocker.Unlock()
+	gdi.allowedCycles[fmt.Sprintf("%v.%v", reflect.TypeOf(ptr), fieldName)] = true
+}
+
+// detectCycles 检测属性注入产生的循环依赖，经过白名单属性的循环会被忽略
+func (gdi *GDIPool) detectCycles() []error {
+	owned := gdi.owned()
+	var roots []interface{}
+	for k := range owned {
+		roots = append(roots, k)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return reflect.TypeOf(roots[i]).String() < reflect.TypeOf(roots[j]).String()
+	})
+	gdi.lc.lock.Lock()
+	defer gdi.lc.lock.Unlock()
+	gdi.creatorLocker.RLock()
+	defer gdi.creatorLocker.RUnlock()
+
+	type frame struct {
+		node  interface{}
+		field string
+	}
+	var errs []error
+	var stack []frame
+	seen := make(map[string]bool)
+	state := make(map[interface{}]int) // 1:访问中 2:已完成
+	var visit func(k interface{})
+	visit = func(k interface{}) {
+		state[k] = 1
+		for _, d := range gdi.lc.deps[k] {
+			dk, ok := lifecycleKey(d.value)
+			if !ok {
+				continue
+			}
+			stack = append(stack, frame{node: k, field: d.field})
+			if state[dk] == 1 {
+				start := 0
+				for i := range stack {
+					if stack[i].node == dk {
+						start = i
+						break
+					}
+				}
+				var path, edges []string
+				allowed := false
+				for _, f := range stack[start:] {
+					edge := fmt.Sprintf("%v.%v", reflect.TypeOf(f.node), f.field)
+					allowed = allowed || gdi.allowedCycles[edge]
+					path = append(path, edge)
+					edges = append(edges, edge)
+				}
+				path = append(path, reflect.TypeOf(dk).String())
+				sort.Strings(edges)
+				if sign := strings.Join(edges, ","); !allowed && !seen[sign] {
+					seen[sign] = true
+					errs = append(errs, &CycleError{Path: path})
+				}
+			} else if state[dk] == 0 {
+				visit(dk)
+			}
+			stack = stack[:len(stack)-1]
+		}
+		state[k] = 2
+	}
+	for _, k := range roots {
+		if state[k] == 0 {
+			visit(k)
+		}
+	}
+	return errs
+}
+
+// creatorCycle 返回从 n 出发的构造函数循环依赖路径
+func creatorCycle(n *creatorNode, nodes map[reflect.Type]*creatorNode) []*creatorNode {
+	index := make(map[*creatorNode]int)
+	var path []*creatorNode
+	for n != nil {
+		if i, ok := index[n]; ok {
+			return append(path[i:], n)
+		}
+		index[n] = len(path)
+		path = append(path, n)
+		var next *creatorNode
+		for _, t := range n.deps {
+			if d := nodes[t]; d.inDegree > 0 {
+				next = d
+				break
+			}
+		}
+		n = next
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package gdi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type cycAA struct{ B *cycBB }
+type cycBB struct{ D *cycDD }
+type cycDD struct{ E *cycEE }
+type cycEE struct{ A *cycAA }
+
+type cycX struct{}
+type cycY struct{}
+
+func TestStrictCycle(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Strict(true)
+	gp.Register(&cycAA{}, &cycBB{}, &cycDD{}, &cycEE{})
+	err := gp.InitE()
+	var ce *CycleError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expect cycle error got %v", err)
+	}
+	if got := strings.Join(ce.Path, " -> "); got != "*gdi.cycAA.B -> *gdi.cycBB.D -> *gdi.cycDD.E -> *gdi.cycEE.A -> *gdi.cycAA" {
+		t.Fatalf("unexpected cycle path %v", got)
+	}
+
+	gp = NewGDIPool()
+	gp.Debug(false)
+	gp.Strict(true)
+	gp.AllowCycle((*cycEE)(nil), "A")
+	gp.Register(&cycAA{}, &cycBB{}, &cycDD{}, &cycEE{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreatorCycle(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func(y *cycY) *cycX {
+		return &cycX{}
+	}, func(x *cycX) *cycY {
+		return &cycY{}
+	})
+	err := gp.InitE()
+	var ce *CycleError
+	if !errors.As(err, &ce) || strings.Join(ce.Path, " -> ") != "*gdi.cycX -> *gdi.cycY -> *gdi.cycX" {
+		t.Fatalf("expect constructor cycle error got %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package gdi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Decorate 注册装饰函数，第一个参数与返回值的类型相同(接口或指针)，其余参数从容器中注入，
+// Init 时用返回值替换容器中的对象，所有使用该类型的地方都会注入装饰后的对象，同一类型的多个装饰函数按注册顺序依次包装
+// Example：gdi.Decorate(func(inner Repo, cache *Cache) Repo { return &CachedRepo{inner: inner, cache: cache} })
+func Decorate(decorator interface{}) error {
+	return globalGDI.Decorate(decorator)
+}
+
+// Decorate 注册装饰函数，第一个参数与返回值的类型相同(接口或指针)，其余参数从容器中注入，
+// Init 时用返回值替换容器中的对象，所有使用该类型的地方都会注入装饰后的对象，同一类型的多个装饰函数按注册顺序依次包装
+// Example：gdi.Decorate(func(inner Repo, cache *Cache) Repo { return &CachedRepo{inner: inner, cache: cache} })
+func (gdi *GDIPool) Decorate(decorator interface{}) error {
+	ftype := reflect.TypeOf(decorator)
+	if ftype == nil || ftype.Kind() != reflect.Func {
+		return fmt.Errorf("(ERROR) decorator %v it's not a func", ftype)
+	}
+	if ftype.NumIn() == 0 || ftype.NumOut() == 0 || ftype.In(0) != ftype.Out(0) {
+		return fmt.Errorf("(ERROR) the first parameter and return value of decorator %v must be the same type", ftype)
+	}
+	if t := ftype.Out(0); t.Kind() != reflect.Interface && t.Kind() != reflect.Ptr {
+		return fmt.Errorf("(ERROR) decorator %v just support a interface or a pointer", ftype)
+	}
+	if ftype.NumOut() > 2 || ftype.NumOut() == 2 && ftype.Out(1) != errorType {
+		return fmt.Errorf("(ERROR) decorator %v should return the decorated value with an optional error", ftype)
+	}
+	gdi.creatorLocker.Lock()
+	defer gdi.creatorLocker.Unlock()
+	gdi.decorators = append(gdi.decorators, decorator)
+	return nil
+}
+
+// decoratedIface 接口的装饰信息，impl/qualifier 选中的实现也要经过同样的装饰函数
+type decoratedIface struct {
+	base       reflect.Type                   // 默认实现(装饰到 decorated 中的对象)的类型
+	decorators []interface{}                  // 按顺序执行过的装饰函数
+	impls      map[reflect.Type]reflect.Value // 其它实现装饰后的对象
+}
+
+// getDecorated 获取装饰后的接口实现
+func (gdi *GDIPool) getDecorated(i reflect.Type) (reflect.Value, bool) {
+	for p := gdi; p != nil; p = p.parent {
+		p.creatorLocker.RLock()
+		v, ok := p.decorated[i]
+		p.creatorLocker.RUnlock()
+		if ok {
+			return v, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// applyDecorators 按注册顺序调用还未执行的装饰函数，指针类型直接替换容器中的对象，接口类型保存到 decorated 中
+func (gdi *GDIPool) applyDecorators() []error {
+	gdi.creatorLocker.Lock()
+	decorators := gdi.decorators
+	gdi.decorators = nil
+	gdi.creatorLocker.Unlock()
+	var errs []error
+	for _, decorator := range decorators {
+		ftype := reflect.TypeOf(decorator)
+		t := ftype.Out(0)
+		fail := func(err error) {
+			errs = append(errs, &CreateError{Type: t.String(), Creator: ftype.String(), Err: fmt.Errorf("decorate fail: %v", err)})
+		}
+		var inner reflect.Value
+		if t.Kind() == reflect.Interface {
+			v, err := gdi.resolveInterface(t)
+			if err != nil {
+				fail(err)
+				continue
+			}
+			inner = v
+		} else if v, ok := gdi.get(t); ok {
+			inner = v
+		} else {
+			fail(fmt.Errorf("type %v not register", t))
+			continue
+		}
+		if inner.Kind() == reflect.Ptr {
+			gdi.build(inner, true, false)
+		}
+		value, err := gdi.callDecorator(decorator, inner)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		if t.Kind() == reflect.Interface {
+			if err = gdi.addIfaceDecorator(t, inner, decorator); err != nil {
+				fail(err)
+				continue
+			}
+		}
+		gdi.replaceDecorated(t, value)
+		gdi.log(fmt.Sprintf("decorate type:%v by %v success", t, ftype), "event", eventCreate, "type", t.String(), "decorator", ftype.String())
+	}
+	return errs
+}
+
+// callDecorator 调用装饰函数，第一个参数为被装饰的对象，其余参数从容器中注入
+func (gdi *GDIPool) callDecorator(decorator interface{}, inner reflect.Value) (reflect.Value, error) {
+	ftype := reflect.TypeOf(decorator)
+	args := []reflect.Value{inner}
+	for n := 1; n < ftype.NumIn(); n++ {
+		arg, ok := gdi.lazyArg(ftype.In(n), decorator)
+		if !ok {
+			arg, ok = gdi.resolve(ftype.In(n))
+		}
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("missing parameter type %v", ftype.In(n))
+		}
+		args = append(args, arg)
+	}
+	values := reflect.ValueOf(decorator).Call(args)
+	if len(values) == 2 && !values[1].IsNil() {
+		return reflect.Value{}, values[1].Interface().(error)
+	}
+	if values[0].IsNil() {
+		return reflect.Value{}, fmt.Errorf("decorator return nil")
+	}
+	return values[0], nil
+}
+
+// addIfaceDecorator 记录接口的装饰函数，已经通过 impl/qualifier 装饰过的其它实现同样再包装一层
+func (gdi *GDIPool) addIfaceDecorator(t reflect.Type, inner reflect.Value, decorator interface{}) error {
+	gdi.creatorLocker.Lock()
+	d, ok := gdi.decoratedIfaces[t]
+	if !ok {
+		d = &decoratedIface{base: inner.Type(), impls: make(map[reflect.Type]reflect.Value)}
+		gdi.decoratedIfaces[t] = d
+	}
+	d.decorators = append(d.decorators, decorator)
+	impls := copyMap(d.impls).(map[reflect.Type]reflect.Value)
+	gdi.creatorLocker.Unlock()
+	for implType, impl := range impls {
+		value, err := gdi.callDecorator(decorator, impl)
+		if err != nil {
+			return fmt.Errorf("%v %v", implType, err)
+		}
+		gdi.creatorLocker.Lock()
+		d.impls[implType] = value.Elem()
+		gdi.creatorLocker.Unlock()
+	}
+	return nil
+}
+
+// decorateImpl 将接口装饰函数应用到 impl/qualifier 选中的实现上，默认实现直接使用已装饰的对象，
+// 装饰后的单例会被缓存，原型每次重新装饰
+func (gdi *GDIPool) decorateImpl(i reflect.Type, implType reflect.Type, impl reflect.Value) (reflect.Value, error) {
+	for p := gdi; p != nil; p = p.parent {
+		p.creatorLocker.RLock()
+		d, ok := p.decoratedIfaces[i]
+		var value reflect.Value
+		var cached bool
+		var decorators []interface{}
+		if ok {
+			value, cached = d.impls[implType]
+			decorators = append(decorators, d.decorators...)
+		}
+		p.creatorLocker.RUnlock()
+		if !ok {
+			continue
+		}
+		if cached {
+			return value, nil
+		}
+		if implType == d.base {
+			if value, ok = p.getDecorated(i); ok {
+				return value, nil
+			}
+		}
+		value = impl
+		for _, decorator := range decorators {
+			v, err := gdi.callDecorator(decorator, value)
+			if err != nil {
+				return reflect.Value{}, &CreateError{Type: i.String(), Creator: reflect.TypeOf(decorator).String(), Err: fmt.Errorf("decorate fail: %v", err)}
+			}
+			value = v.Elem()
+		}
+		if !gdi.isPrototype(implType) {
+			p.creatorLocker.Lock()
+			d.impls[implType] = value
+			p.creatorLocker.Unlock()
+		}
+		return value, nil
+	}
+	return impl, nil
+}
+
+// replaceDecorated 保存装饰后的对象
+func (gdi *GDIPool) replaceDecorated(t reflect.Type, value reflect.Value) {
+	gdi.creatorLocker.Lock()
+	defer gdi.creatorLocker.Unlock()
+	gdi.ttvLocker.Lock()
+	defer gdi.ttvLocker.Unlock()
+	gdi.markProvenance(value, ProvenanceDecorator)
+	if t.Kind() == reflect.Interface {
+		gdi.decorated[t] = value.Elem()
+		return
+	}
+	if _, ok := gdi.typeToValuesReadOnly[t]; ok {
+		gdi.typeToValuesReadOnly[t] = value
+	} else {
+		gdi.typeToValues[t] = value
+	}
+}
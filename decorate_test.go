@@ -0,0 +1,129 @@
+package gdi
+
+import (
+	"testing"
+)
+
+type dcRepo interface {
+	Find() string
+}
+
+type dcMySQLRepo struct{}
+
+func (r *dcMySQLRepo) Find() string { return "mysql" }
+
+type dcCache struct{ Prefix string }
+
+type dcCachedRepo struct {
+	inner dcRepo
+	cache *dcCache
+}
+
+func (r *dcCachedRepo) Find() string { return r.cache.Prefix + r.inner.Find() }
+
+type dcMetricsRepo struct{ inner dcRepo }
+
+func (r *dcMetricsRepo) Find() string { return "metrics(" + r.inner.Find() + ")" }
+
+type dcClient struct{ Name string }
+
+type dcService struct {
+	Repo   dcRepo
+	Client *dcClient
+}
+
+func TestDecorate(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&dcMySQLRepo{}, &dcCache{Prefix: "cache:"}, &dcService{})
+	gp.RegisterReadOnly(&dcClient{Name: "third-party"})
+	if err := gp.Decorate(func(inner dcRepo, cache *dcCache) dcRepo {
+		return &dcCachedRepo{inner: inner, cache: cache}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gp.Decorate(func(inner dcRepo) dcRepo {
+		return &dcMetricsRepo{inner: inner}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gp.Decorate(func(inner *dcClient) (*dcClient, error) {
+		return &dcClient{Name: "logged(" + inner.Name + ")"}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	svc := gp.Get(&dcService{}).(*dcService)
+	if got := svc.Repo.Find(); got != "metrics(cache:mysql)" {
+		t.Fatalf("decorators should stack in declared order, got %v", got)
+	}
+	if svc.Client.Name != "logged(third-party)" {
+		t.Fatalf("pointer decorator not applied, got %v", svc.Client.Name)
+	}
+	if repo, err := Resolve[dcRepo](gp); err != nil || repo.Find() != "metrics(cache:mysql)" {
+		t.Fatalf("resolve should return the decorated value, got %v %v", repo, err)
+	}
+}
+
+func TestDecorateInvalid(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	invalid := []interface{}{
+		&dcMySQLRepo{},
+		func(inner dcRepo) *dcMySQLRepo { return nil },
+		func(inner dcCache) dcCache { return inner },
+		func(inner dcRepo) (dcRepo, string) { return inner, "" },
+	}
+	for _, d := range invalid {
+		if err := gp.Decorate(d); err == nil {
+			t.Fatalf("expected error for %T", d)
+		}
+	}
+	gp.Decorate(func(inner *dcClient) *dcClient { return inner })
+	if err := gp.InitE(); err == nil {
+		t.Fatal("decorating an unregistered type should fail")
+	}
+}
+
+type dcMongoRepo struct{}
+
+func (r *dcMongoRepo) Find() string { return "mongo" }
+
+type dcQualifiedService struct {
+	Default dcRepo
+	MySQL   dcRepo `inject:"impl:gdi.dcMySQLRepo"`
+	Mongo   dcRepo `inject:"impl:gdi.dcMongoRepo"`
+	Backup  dcRepo `inject:"qualifier:backup"`
+}
+
+func TestDecorateQualified(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&dcMySQLRepo{}, &dcMongoRepo{}, &dcCache{Prefix: "cache:"}, &dcQualifiedService{})
+	gp.Bind((*dcRepo)(nil), (*dcMySQLRepo)(nil))
+	gp.BindQualified((*dcRepo)(nil), "backup", (*dcMongoRepo)(nil))
+	gp.Decorate(func(inner dcRepo, cache *dcCache) dcRepo {
+		return &dcCachedRepo{inner: inner, cache: cache}
+	})
+	gp.Decorate(func(inner dcRepo) dcRepo {
+		return &dcMetricsRepo{inner: inner}
+	})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	svc := gp.Get(&dcQualifiedService{}).(*dcQualifiedService)
+	if got := svc.MySQL.Find(); got != "metrics(cache:mysql)" {
+		t.Fatalf("impl field should inject the decorated value, got %v", got)
+	}
+	if svc.MySQL != svc.Default {
+		t.Fatal("impl field of the default implementation should share the decorated value")
+	}
+	if got := svc.Mongo.Find(); got != "metrics(cache:mongo)" {
+		t.Fatalf("decorators should also wrap other implementations, got %v", got)
+	}
+	if svc.Backup != svc.Mongo {
+		t.Fatal("decorated implementation should be created only once")
+	}
+}
@@ -0,0 +1,181 @@
+package gdi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// Provenance 对象的来源
+type Provenance string
+
+const (
+	ProvenancePointer     Provenance = "pointer"     // 注册的对象指针
+	ProvenanceConstructor Provenance = "constructor" // 构造函数创建
+	ProvenanceAutoCreate  Provenance = "autoCreate"  // 注入时自动创建
+	ProvenanceTest        Provenance = "test"        // 单元测试中使用已有的属性值
+	ProvenanceDecorator   Provenance = "decorator"   // 装饰函数返回
+)
+
+// ObjectDescription 容器中的对象
+type ObjectDescription struct {
+	Type         string                  `json:"type"`
+	Name         string                  `json:"name,omitempty"`
+	Interface    string                  `json:"interface,omitempty"` // 装饰后的接口
+	ReadOnly     bool                    `json:"readOnly"`
+	Provenance   Provenance              `json:"provenance"`
+	Dependencies []DependencyDescription `json:"dependencies,omitempty"`
+	Dependents   []DependencyDescription `json:"dependents,omitempty"`
+}
+
+// DependencyDescription 注入关系，Field 为依赖方的属性，Type 为另一方的类型
+type DependencyDescription struct {
+	Field string `json:"field"`
+	Type  string `json:"type"`
+}
+
+// Describe 列出全局容器中的对象
+func Describe() []ObjectDescription {
+	return globalGDI.Describe()
+}
+
+// DescribeJSON 以 JSON 格式列出全局容器中的对象
+func DescribeJSON() ([]byte, error) {
+	return globalGDI.DescribeJSON()
+}
+
+// DescribeHandler 以 JSON 格式输出全局容器中的对象，用于调试
+// Example：http.Handle("/debug/gdi", gdi.DescribeHandler())
+func DescribeHandler() http.Handler {
+	return globalGDI.DescribeHandler()
+}
+
+// setAutoCreated 保存自动创建的对象
+func (gdi *GDIPool) setAutoCreated(t reflect.Type, value reflect.Value) {
+	gdi.set(t, value.Interface())
+	gdi.ttvLocker.Lock()
+	defer gdi.ttvLocker.Unlock()
+	gdi.markProvenance(value, ProvenanceAutoCreate)
+}
+
+// markProvenance 记录对象的来源，调用方需持有 ttvLocker
+func (gdi *GDIPool) markProvenance(v reflect.Value, p Provenance) {
+	if k, ok := lifecycleKey(v); ok {
+		gdi.provenance[k] = p
+	}
+}
+
+// Describe 列出容器中按类型、名称注册的对象及单元测试中使用的属性值，包括来源、依赖的对象及被哪些对象依赖，按名称、类型排序
+func (gdi *GDIPool) Describe() []ObjectDescription {
+	type entry struct {
+		desc ObjectDescription
+		key  interface{}
+	}
+	var entries []*entry
+	byKey := make(map[interface{}][]*entry)
+	add := func(desc ObjectDescription, v reflect.Value) {
+		e := &entry{desc: desc}
+		if k, ok := lifecycleKey(v); ok {
+			e.key = k
+			byKey[k] = append(byKey[k], e)
+		}
+		entries = append(entries, e)
+	}
+	gdi.creatorLocker.RLock()
+	gdi.ttvLocker.RLock()
+	provenance := func(v reflect.Value) Provenance {
+		if k, ok := lifecycleKey(v); ok {
+			if p, ok := gdi.provenance[k]; ok {
+				return p
+			}
+		}
+		return ProvenancePointer
+	}
+	for t, v := range gdi.typeToValues {
+		add(ObjectDescription{Type: t.String(), Provenance: provenance(v)}, v)
+	}
+	for t, v := range gdi.typeToValuesReadOnly {
+		if t == contextType {
+			continue
+		}
+		add(ObjectDescription{Type: t.String(), ReadOnly: true, Provenance: provenance(v)}, v)
+	}
+	for name, v := range gdi.namesToValues {
+		add(ObjectDescription{Type: v.Type().String(), Name: name, Provenance: provenance(v)}, v)
+	}
+	for name, v := range gdi.namesToValuesReadOnly {
+		add(ObjectDescription{Type: v.Type().String(), Name: name, ReadOnly: true, Provenance: provenance(v)}, v)
+	}
+	for t, v := range gdi.typeToValuesForTest {
+		add(ObjectDescription{Type: t.String(), Provenance: ProvenanceTest}, v)
+	}
+	for i, v := range gdi.decorated {
+		add(ObjectDescription{Type: v.Type().String(), Interface: i.String(), Provenance: ProvenanceDecorator}, v)
+	}
+	gdi.ttvLocker.RUnlock()
+	gdi.creatorLocker.RUnlock()
+
+	gdi.lc.lock.Lock()
+	for k, deps := range gdi.lc.deps {
+		owner := reflect.TypeOf(k).String()
+		for _, d := range deps {
+			dk, ok := lifecycleKey(d.value)
+			if !ok {
+				continue
+			}
+			for _, e := range byKey[k] {
+				e.desc.Dependencies = append(e.desc.Dependencies, DependencyDescription{Field: d.field, Type: reflect.TypeOf(dk).String()})
+			}
+			for _, e := range byKey[dk] {
+				e.desc.Dependents = append(e.desc.Dependents, DependencyDescription{Field: d.field, Type: owner})
+			}
+		}
+	}
+	gdi.lc.lock.Unlock()
+
+	result := make([]ObjectDescription, 0, len(entries))
+	for _, e := range entries {
+		sortDependencies(e.desc.Dependencies)
+		sortDependencies(e.desc.Dependents)
+		result = append(result, e.desc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].Interface < result[j].Interface
+	})
+	return result
+}
+
+func sortDependencies(deps []DependencyDescription) {
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Type != deps[j].Type {
+			return deps[i].Type < deps[j].Type
+		}
+		return deps[i].Field < deps[j].Field
+	})
+}
+
+// DescribeJSON 以 JSON 格式列出容器中的对象
+func (gdi *GDIPool) DescribeJSON() ([]byte, error) {
+	return json.MarshalIndent(gdi.Describe(), "", "  ")
+}
+
+// DescribeHandler 以 JSON 格式输出容器中的对象，用于调试
+// Example：http.Handle("/debug/gdi", pool.DescribeHandler())
+func (gdi *GDIPool) DescribeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := gdi.DescribeJSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
@@ -0,0 +1,73 @@
+package gdi
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+type dsDB struct{}
+
+type dsConfig struct{}
+
+type dsAuto struct{}
+
+type dsRepo struct {
+	DB   *dsDB
+	Auto *dsAuto
+}
+
+type dsService struct {
+	Repo   *dsRepo
+	Config *dsConfig
+}
+
+func TestDescribe(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func(cfg *dsConfig) *dsRepo { return &dsRepo{} }, &dsService{}, &dsDB{})
+	gp.RegisterReadOnly(&dsConfig{})
+	gp.Register(func() (*dsDB, string) { return &dsDB{}, "replica" })
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	objs := make(map[string]ObjectDescription)
+	for _, o := range gp.Describe() {
+		objs[o.Name+o.Type] = o
+	}
+	expects := map[string]ObjectDescription{
+		"*gdi.dsService":   {Provenance: ProvenancePointer},
+		"*gdi.dsRepo":      {Provenance: ProvenanceConstructor},
+		"*gdi.dsConfig":    {Provenance: ProvenancePointer, ReadOnly: true},
+		"*gdi.dsAuto":      {Provenance: ProvenanceAutoCreate},
+		"replica*gdi.dsDB": {Provenance: ProvenanceConstructor},
+	}
+	for k, e := range expects {
+		o, ok := objs[k]
+		if !ok {
+			t.Fatalf("%v not described", k)
+		}
+		if o.Provenance != e.Provenance || o.ReadOnly != e.ReadOnly {
+			t.Fatalf("%v: unexpected %+v", k, o)
+		}
+	}
+	repo := objs["*gdi.dsRepo"]
+	if len(repo.Dependencies) != 3 || repo.Dependencies[0] != (DependencyDescription{Field: "Auto", Type: "*gdi.dsAuto"}) ||
+		repo.Dependencies[1] != (DependencyDescription{Field: "arg#0", Type: "*gdi.dsConfig"}) ||
+		repo.Dependencies[2] != (DependencyDescription{Field: "DB", Type: "*gdi.dsDB"}) {
+		t.Fatalf("unexpected dependencies %+v", repo.Dependencies)
+	}
+	if len(repo.Dependents) != 1 || repo.Dependents[0] != (DependencyDescription{Field: "Repo", Type: "*gdi.dsService"}) {
+		t.Fatalf("unexpected dependents %+v", repo.Dependents)
+	}
+
+	rec := httptest.NewRecorder()
+	gp.DescribeHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/gdi", nil))
+	var decoded []ObjectDescription
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(objs) || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response %v", rec.Body.String())
+	}
+}
@@ -0,0 +1,83 @@
+package gdi
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// InjectError 属性注入失败的详细信息
+type InjectError struct {
+	Type      string // 被注入的结构体类型
+	Field     string // 属性名
+	FieldType string // 属性类型
+	PkgPath   string // 结构体所在的包
+	Err       error  // 失败原因
+}
+
+func (e *InjectError) Error() string {
+	msg := fmt.Sprintf("inject fieldName:%v->%v of %v pkgPath:%v", e.Field, e.FieldType, e.Type, e.PkgPath)
+	if e.Err != nil {
+		msg = msg + " " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *InjectError) Unwrap() error {
+	return e.Err
+}
+
+// CreateError 构造函数创建对象失败的详细信息
+type CreateError struct {
+	Type    string // 构造函数返回的类型
+	Creator string // 构造函数类型
+	Err     error  // 失败原因
+}
+
+func (e *CreateError) Error() string {
+	return fmt.Sprintf("create %v by %v fail %v", e.Type, e.Creator, e.Err)
+}
+
+func (e *CreateError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError 多个错误的集合，InitE 和 DIE 会返回所有的错误而不是遇到第一个就退出
+type MultiError []error
+
+func (m MultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("(ERROR) %v error(s) occurred:\n\t%v", len(m), strings.Join(msgs, "\n\t"))
+}
+
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+// errorCollector 收集 InitE/DIE 过程中的错误
+type errorCollector struct {
+	lock    sync.Mutex
+	errs    MultiError
+	onError func(err error)
+}
+
+func (c *errorCollector) add(err error) {
+	c.lock.Lock()
+	c.errs = append(c.errs, err)
+	c.lock.Unlock()
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
+
+func (c *errorCollector) err() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return c.errs
+}
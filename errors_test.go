@@ -0,0 +1,98 @@
+package gdi
+
+import (
+	"errors"
+	"testing"
+)
+
+type errRepo interface {
+	Find(id int) string
+}
+
+type errMissing struct {
+	Name string
+}
+
+type errService struct {
+	Repo    errRepo
+	Missing *errMissing
+	Named   *string `inject:"name:not_exists"`
+}
+
+type errBroken struct{}
+
+func TestInitE(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.AutoCreate(false)
+	gp.Register(&errService{})
+	gp.Register(func(s *errService) (*errBroken, error) {
+		return nil, errors.New("broken")
+	})
+	err := gp.InitE()
+	if err == nil {
+		t.Fatal("InitE should return error")
+	}
+	var me MultiError
+	if !errors.As(err, &me) {
+		t.Fatalf("expect MultiError got %T", err)
+	}
+	var fields []string
+	var creates int
+	for _, e := range me {
+		var ie *InjectError
+		var ce *CreateError
+		if errors.As(e, &ie) {
+			if ie.Type != "*gdi.errService" || ie.PkgPath != "github.com/sjqzhang/gdi" {
+				t.Fatalf("unexpected inject error %v", ie)
+			}
+			fields = append(fields, ie.Field)
+		} else if errors.As(e, &ce) {
+			creates++
+		}
+	}
+	if len(fields) != 3 || creates != 1 {
+		t.Fatalf("expect 3 inject errors and 1 create error got %v", err)
+	}
+
+	var s errService
+	if err := gp.DIE(&s); err == nil {
+		t.Fatal("DIE should return error")
+	}
+}
+
+type optMailer struct{}
+
+type optNotifier interface {
+	Notify(msg string)
+}
+
+type optService struct {
+	Mailer   *optMailer  `inject:"optional"`
+	Notifier optNotifier `inject:"optional"`
+	Manual   *optMailer  `inject:"-"`
+	Required *errMissing `inject:"required"`
+	Auto     *errMissing
+}
+
+func TestInjectOption(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&optService{})
+	err := gp.InitE()
+	var me MultiError
+	if !errors.As(err, &me) || len(me) != 1 {
+		t.Fatalf("expect only the required field fail got %v", err)
+	}
+	var ie *InjectError
+	if !errors.As(me[0], &ie) || ie.Field != "Required" {
+		t.Fatalf("expect required field error got %v", me[0])
+	}
+	s := MustResolve[*optService](gp)
+	if s.Mailer != nil || s.Notifier != nil || s.Manual != nil {
+		t.Fatal("optional and skipped fields should be nil")
+	}
+	if s.Auto == nil {
+		t.Fatal("field without option should be auto created")
+	}
+}
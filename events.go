@@ -0,0 +1,115 @@
+package gdi
+
+import (
+	"reflect"
+	"time"
+)
+
+// RegisterEvent 对象保存到容器中，Name 为空表示按类型注册
+type RegisterEvent struct {
+	Type     reflect.Type
+	Name     string
+	ReadOnly bool
+}
+
+// CreateEvent 调用构造函数创建对象，Duration 为构造函数的耗时
+type CreateEvent struct {
+	Type     reflect.Type
+	Creator  reflect.Type
+	Duration time.Duration
+	Err      error
+}
+
+// InjectEvent 对象 Owner 的属性 Field 注入了 Value
+type InjectEvent struct {
+	Owner     reflect.Type
+	Field     string
+	FieldType reflect.Type
+	Value     interface{}
+	PkgPath   string
+}
+
+// AutoCreateEvent 没有注册的类型 Type 在注入 Owner 的属性 Field 时被自动创建
+type AutoCreateEvent struct {
+	Type  reflect.Type
+	Owner reflect.Type
+	Field string
+}
+
+// Listener 容器事件监听，不需要的事件可以不设定，监听函数中不能再调用容器的方法
+// Example：gdi.AddListener(gdi.Listener{OnCreate: func(e gdi.CreateEvent) { fmt.Println(e.Type, e.Duration) }})
+type Listener struct {
+	OnRegister   func(e RegisterEvent)
+	OnCreate     func(e CreateEvent)
+	OnInject     func(e InjectEvent)
+	OnAutoCreate func(e AutoCreateEvent)
+	OnError      func(err error)
+}
+
+// AddListener 添加容器事件监听
+func AddListener(l Listener) {
+	globalGDI.AddListener(l)
+}
+
+// AddListener 添加容器事件监听，子容器的事件也会通知到父容器的监听
+func (gdi *GDIPool) AddListener(l Listener) {
+	gdi.listenerLocker.Lock()
+	defer gdi.listenerLocker.Unlock()
+	gdi.listeners = append(gdi.listeners, l)
+}
+
+// emit 通知当前容器及父容器的监听
+func (gdi *GDIPool) emit(notify func(l Listener)) {
+	for p := gdi; p != nil; p = p.parent {
+		p.listenerLocker.RLock()
+		listeners := p.listeners
+		p.listenerLocker.RUnlock()
+		for _, l := range listeners {
+			notify(l)
+		}
+	}
+}
+
+func (gdi *GDIPool) emitRegister(e RegisterEvent) {
+	gdi.emit(func(l Listener) {
+		if l.OnRegister != nil {
+			l.OnRegister(e)
+		}
+	})
+}
+
+func (gdi *GDIPool) emitCreate(e CreateEvent) {
+	gdi.emit(func(l Listener) {
+		if l.OnCreate != nil {
+			l.OnCreate(e)
+		}
+	})
+}
+
+func (gdi *GDIPool) emitInject(owner reflect.Value, fieldName string, field reflect.Value, pkgPath string) {
+	gdi.emit(func(l Listener) {
+		if l.OnInject != nil {
+			e := InjectEvent{Owner: owner.Type(), Field: fieldName, FieldType: field.Type(), PkgPath: pkgPath}
+			if field.CanInterface() {
+				e.Value = field.Interface()
+			}
+			l.OnInject(e)
+		}
+	})
+}
+
+func (gdi *GDIPool) emitAutoCreate(e AutoCreateEvent) {
+	gdi.emit(func(l Listener) {
+		if l.OnAutoCreate != nil {
+			l.OnAutoCreate(e)
+		}
+	})
+}
+
+func (gdi *GDIPool) emitError(err error) {
+	gdi.emit(func(l Listener) {
+		if l.OnError != nil {
+			l.OnError(err)
+		}
+	})
+}
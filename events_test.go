@@ -0,0 +1,82 @@
+package gdi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type evDB struct{}
+
+type evRepo struct {
+	DB *evDB
+}
+
+type evService struct {
+	Repo *evRepo
+	Auto *evAuto
+}
+
+type evAuto struct{}
+
+type evBroken struct {
+	Missing *evMissing `inject:"required"`
+}
+
+type evMissing struct{}
+
+func TestListener(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	registered := make(map[string]bool)
+	created := make(map[string]time.Duration)
+	injected := make(map[string]bool)
+	var autoCreated []AutoCreateEvent
+	var errs []error
+	gp.AddListener(Listener{
+		OnRegister: func(e RegisterEvent) { registered[e.Type.String()] = true },
+		OnCreate:   func(e CreateEvent) { created[e.Type.String()] = e.Duration },
+		OnInject: func(e InjectEvent) {
+			injected[e.Owner.String()+"."+e.Field] = true
+		},
+		OnAutoCreate: func(e AutoCreateEvent) { autoCreated = append(autoCreated, e) },
+		OnError:      func(err error) { errs = append(errs, err) },
+	})
+	gp.Register(func() *evDB {
+		time.Sleep(time.Millisecond)
+		return &evDB{}
+	}, func(db *evDB) (*evRepo, error) {
+		return &evRepo{DB: db}, nil
+	}, &evService{}, &evBroken{})
+	err := gp.InitE()
+
+	if !registered["*gdi.evDB"] || !registered["*gdi.evRepo"] || !registered["*gdi.evService"] {
+		t.Fatalf("missing register events %v", registered)
+	}
+	if d, ok := created["*gdi.evDB"]; !ok || d < time.Millisecond {
+		t.Fatalf("create event should contain duration, got %v", created)
+	}
+	if _, ok := created["*gdi.evRepo"]; !ok {
+		t.Fatal("missing create event of constructor with parameters")
+	}
+	if !injected["*gdi.evService.Repo"] || !injected["*gdi.evService.Auto"] {
+		t.Fatalf("missing inject events %v", injected)
+	}
+	if len(autoCreated) != 1 || autoCreated[0].Type.String() != "*gdi.evAuto" || autoCreated[0].Owner.String() != "*gdi.evService" {
+		t.Fatalf("unexpected auto create events %v", autoCreated)
+	}
+	var ie *InjectError
+	if err == nil || len(errs) != 1 || !errors.As(errs[0], &ie) || ie.Field != "Missing" {
+		t.Fatalf("unexpected error events %v", errs)
+	}
+
+	scope := gp.NewScope(context.Background())
+	injected = make(map[string]bool)
+	if err := scope.DI(&evService{}); err != nil {
+		t.Fatal(err)
+	}
+	if !injected["*gdi.evService.Repo"] {
+		t.Fatal("events of scope should notify listeners of parent")
+	}
+}
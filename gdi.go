@@ -1,17 +1,18 @@
 package gdi
 
 import (
+	"context"
 	"embed"
 	"errors"
 	"fmt"
 	"github.com/sjqzhang/gdi/tl"
-	"io/ioutil"
 	"log"
 	"os"
 	"reflect"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 	"unsafe"
 )
 
@@ -35,6 +36,9 @@ type GDIPool struct {
 	ignoreInterface       bool
 	ignorePrivate         bool
 	creator               map[reflect.Type]interface{}
+	invoked               map[reflect.Type]bool
+	prototypes            map[reflect.Type]interface{}
+	scoped                map[reflect.Type]interface{}
 	creatorLocker         sync.RWMutex
 	typeToValuesForTest   map[reflect.Type]reflect.Value
 	typeToValuesReadOnly  map[reflect.Type]reflect.Value
@@ -43,16 +47,41 @@ type GDIPool struct {
 	namesToValues         map[string]reflect.Value
 	namesToValuesReadOnly map[string]reflect.Value
 	interfaceToImplements map[string]string
+	qualifiers            map[reflect.Type]map[string]reflect.Type
+	config                map[string]string
 	placeHolders          map[string]interface{}
 	g                     *graph
+	lc                    *lifecycle
+	errs                  *errorCollector
 	fs                    *embed.FS
-
-	ttvLocker  sync.RWMutex
-	autoCreate bool
+	src                   *sourceCache
+	parent                *GDIPool
+	ctx                   context.Context
+	closed                bool // 子容器已调用 Close
+
+	logger   Logger
+	logLevel LogLevel
+	noColor  bool
+
+	listenerLocker sync.RWMutex
+	listeners      []Listener
+
+	ttvLocker     sync.RWMutex
+	autoCreate    bool
+	strict        bool
+	allowedCycles map[string]bool
+	profiles      []string
+	pending       []pendingRegistration
+	decorators    []interface{}
+	decorated     map[reflect.Type]reflect.Value
+	provenance    map[interface{}]Provenance
+
+	consoleLog           *log.Logger
+	graphExportersLocker sync.RWMutex
+	graphExporters       map[string]GraphExporter
+	decoratedIfaces      map[reflect.Type]*decoratedIface // 接口装饰函数，用于装饰 impl/qualifier 选中的实现
 }
 
-var consoleLog = log.New(os.Stdout, "[gdi] ", log.LstdFlags)
-
 func init() {
 	globalGDI = NewGDIPool()
 }
@@ -68,6 +97,9 @@ func NewGDIPool() *GDIPool {
 		autoCreate:            true,
 		ignorePrivate:         false,
 		creator:               make(map[reflect.Type]interface{}),
+		invoked:               make(map[reflect.Type]bool),
+		prototypes:            make(map[reflect.Type]interface{}),
+		scoped:                make(map[reflect.Type]interface{}),
 		creatorLocker:         sync.RWMutex{},
 		allTypesToValues:      make(map[reflect.Type]reflect.Value),
 		typeToValuesForTest:   make(map[reflect.Type]reflect.Value),
@@ -76,9 +108,20 @@ func NewGDIPool() *GDIPool {
 		namesToValues:         make(map[string]reflect.Value),
 		namesToValuesReadOnly: make(map[string]reflect.Value),
 		interfaceToImplements: make(map[string]string),
+		qualifiers:            make(map[reflect.Type]map[string]reflect.Type),
+		config:                make(map[string]string),
 		ttvLocker:             sync.RWMutex{},
 		g:                     &graph{lock: sync.Mutex{}},
+		lc:                    newLifecycle(),
 		placeHolders:          make(map[string]interface{}),
+		src:                   newSourceCache(),
+		allowedCycles:         make(map[string]bool),
+		profiles:              profilesFromEnv(),
+		decorated:             make(map[reflect.Type]reflect.Value),
+		decoratedIfaces:       make(map[reflect.Type]*decoratedIface),
+		provenance:            make(map[interface{}]Provenance),
+		consoleLog:            log.New(os.Stdout, "[gdi] ", log.LstdFlags),
+		graphExporters:        defaultGraphExporters(),
 	}
 	pool.g.nodes = map[string]*node{}
 	for _, t := range GetAllTypes() {
@@ -178,7 +221,7 @@ func GetAllTypesByPackName(packageRegexp string) ([]reflect.Type, error) {
 
 //GetWithCheck 从容器中获取值
 func GetWithCheck(t interface{}) (value interface{}, ok bool) {
-	return globalGDI.GetWithCheck(value)
+	return globalGDI.GetWithCheck(t)
 }
 
 //Init 在使用前必须先调用它
@@ -186,6 +229,21 @@ func Init() {
 	globalGDI.Init()
 }
 
+// InitE 与 Init 相同，但不会panic或退出，而是返回所有的错误
+func InitE() error {
+	return globalGDI.InitE()
+}
+
+// InitContext 与 InitE 相同，但按依赖关系并发调用构造函数，并在 ctx 结束(如启动超时)时立即返回
+func InitContext(ctx context.Context) error {
+	return globalGDI.InitContext(ctx)
+}
+
+// DIE 与 DI 相同，但会返回所有注入失败的属性
+func DIE(pointer interface{}) error {
+	return globalGDI.DIE(pointer)
+}
+
 //func (gdi *GDIPool) IgnoreInterfaceInject(isIgnoreInterfaceInject bool) {
 //	gdi.ignoreInterface = isIgnoreInterfaceInject
 //}
@@ -228,7 +286,7 @@ func (gdi *GDIPool) Register(funcObjOrPtrs ...interface{}) {
 			gdi.warn(fmt.Sprintf("(WARNNING) register %v fail just support a struct pointer or a function return a struct pointer ", ftype))
 			return
 		}
-		if _, ok := gdi.get(ftype); ok {
+		if _, ok := gdi.get(ftype); ok || gdi.isPrototype(ftype) {
 			gdi.panic(fmt.Sprintf("double register %v", ftype))
 			return
 		}
@@ -241,10 +299,15 @@ func (gdi *GDIPool) Register(funcObjOrPtrs ...interface{}) {
 			gdi.panic(err.Error())
 		}
 		if ftype.Kind() == reflect.Func {
-			if ftype.NumIn() == 0 {
-				gdi.set(outType, funcObjOrPtr)
-			} else {
+			if ftype.NumIn() == 0 && ftype.NumOut() == 2 && ftype.Out(1).Kind() == reflect.String {
+				gdi.set(outType, funcObjOrPtr) // 按名称注册的对象在注册时创建
+			} else { // 构造函数在 Init 时调用，错误由 InitE 返回
+				if gdi.isPrototype(outType) {
+					gdi.panic(fmt.Sprintf("double register %v", outType))
+				}
+				gdi.creatorLocker.Lock()
 				gdi.creator[outType] = funcObjOrPtr
+				gdi.creatorLocker.Unlock()
 			}
 		}
 	}
@@ -281,58 +344,55 @@ func (gdi *GDIPool) RegisterReadOnly(funcObjOrPtrs ...interface{}) {
 	}
 }
 
-//Init 在使用前必须先调用它
+//Init 在使用前必须先调用它,出错时panic
 func (gdi *GDIPool) Init() *GDIPool {
+	if err := gdi.InitE(); err != nil {
+		gdi.panic(err.Error())
+	}
+	return gdi
+}
+
+// InitE 与 Init 相同，但会收集所有无法注入的属性、创建失败的构造函数及有歧义的接口，合并成一个 MultiError 返回
+func (gdi *GDIPool) InitE() error {
+	return gdi.init(context.Background(), false)
+}
+
+// InitContext 与 InitE 相同，但按依赖关系并发调用构造函数，参数为 context.Context 的构造函数会传入 ctx，
+// 构造函数失败或 ctx 结束(如启动超时)时取消其余的构造函数并立即返回
+func (gdi *GDIPool) InitContext(ctx context.Context) error {
+	return gdi.init(ctx, true)
+}
+
+func (gdi *GDIPool) init(ctx context.Context, parallel bool) (e error) {
 	gdi.lock.Lock()
 	defer gdi.lock.Unlock()
-
-	for k := 0; k < len(gdi.creator)*len(gdi.creator); k++ {
-		i := len(gdi.creator)
-		j := 0
-		for outype, creator := range gdi.creator {
-			if _, ok := gdi.get(outype); ok {
-				j++
-			} else {
-				funcType := reflect.TypeOf(creator)
-				if funcType.Kind() == reflect.Func {
-					if funcType.NumIn() == 0 {
-						continue
-					}
-					var args []reflect.Value
-					for n := 0; n < funcType.NumIn(); n++ {
-
-						//t:=reflect.TypeOf(funcType.In(n))
-						if itype, ok := gdi.get(funcType.In(n)); ok {
-							args = append(args, itype)
-						} else {
-							break
-						}
-					}
-					if funcType.NumIn() == len(args) {
-						values := reflect.ValueOf(creator).Call(args)
-						if len(values) > 1 && values[1].Kind() == reflect.String {
-							name := values[1].Interface()
-							gdi.namesToValues[name.(string)] = values[0]
-
-						}
-						if len(values) > 1 && values[1].Type().Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-							if values[1].Interface() != nil {
-								gdi.panic(fmt.Sprintf("(ERROR)create %v fail %v", outype, funcType))
-							}
-							gdi.typeToValues[outype] = values[0]
-						}
-						if len(values) == 1 {
-							gdi.typeToValues[outype] = values[0]
-						}
-						gdi.log(fmt.Sprintf("inject type %v over by %v success", outype, funcType))
-					}
-				}
-			}
+	gdi.errs = &errorCollector{onError: gdi.emitError}
+	defer func() {
+		if err := recover(); err != nil {
+			gdi.errs.add(fmt.Errorf("%v", err))
 		}
-		if i == j {
-			break
+		e = gdi.errs.err()
+		gdi.errs = nil
+	}()
+	for _, err := range gdi.applyProfiles() {
+		gdi.errs.add(err)
+	}
+	if parallel {
+		errs := gdi.initCreatorsParallel(ctx)
+		for _, err := range errs {
+			gdi.errs.add(err)
+		}
+		if len(errs) > 0 {
+			return
+		}
+	} else {
+		for _, err := range gdi.initCreators(ctx) {
+			gdi.errs.add(err)
 		}
 	}
+	for _, err := range gdi.applyDecorators() {
+		gdi.errs.add(err)
+	}
 
 	for _, v := range gdi.typeToValues {
 		gdi.build(v, true, false)
@@ -340,10 +400,18 @@ func (gdi *GDIPool) Init() *GDIPool {
 	for _, v := range gdi.namesToValues {
 		gdi.build(v, true, false)
 	}
+	if gdi.strict {
+		for _, err := range gdi.detectCycles() {
+			gdi.errs.add(err)
+		}
+	}
+	if err := gdi.afterInject(); err != nil {
+		gdi.errs.add(err)
+	}
 	//if err:=gdi.checkPoolNil();err!=nil {
 	//	panic(err)
 	//}
-	return gdi
+	return
 }
 func (gdi *GDIPool) checkPoolNil() error {
 	for t, v := range gdi.all() {
@@ -377,6 +445,9 @@ func (gdi *GDIPool) checkPoolNil() error {
 
 func (gdi *GDIPool) all() map[reflect.Type]reflect.Value {
 	objs := make(map[reflect.Type]reflect.Value)
+	if gdi.parent != nil {
+		objs = gdi.parent.all()
+	}
 	gdi.ttvLocker.Lock()
 	defer gdi.ttvLocker.Unlock()
 	for k, v := range gdi.typeToValues {
@@ -394,18 +465,41 @@ func (gdi *GDIPool) pkgInScanPaths(pkg string) bool {
 }
 
 func (gdi *GDIPool) injectLog(fieldName string, field reflect.Value, vStruct reflect.Value, pkgPath string, level logLevel) {
+	msg := fmt.Sprintf("inject fieldName:%v->%v of %v pkgPath:%v", fieldName, field.Type(), vStruct.Type(), pkgPath)
+	attrs := []interface{}{"event", eventInject, "type", vStruct.Type().String(), "field", fieldName, "fieldType", field.Type().String(), "pkgPath", pkgPath}
 	switch level {
 	case logLevelWarning:
-		gdi.warn(fmt.Sprintf("inject fieldName:%v->%v of %v pkgPath:%v", fieldName, field.Type(), vStruct.Type(), pkgPath))
+		gdi.warn(msg, attrs...)
 	case logLevelError:
-		gdi.error(fmt.Sprintf("inject fieldName:%v->%v of %v pkgPath:%v", fieldName, field.Type(), vStruct.Type(), pkgPath))
-	case logLevelPanic:
-		gdi.panic(fmt.Sprintf("inject fieldName:%v->%v of %v pkgPath:%v", fieldName, field.Type(), vStruct.Type(), pkgPath))
-	case logLevelExit:
-		gdi.error(fmt.Sprintf("inject fieldName:%v->%v of %v pkgPath:%v", fieldName, field.Type(), vStruct.Type(), pkgPath))
-		os.Exit(1)
+		gdi.error(msg, attrs...)
+	case logLevelPanic, logLevelExit:
+		gdi.panic(msg, attrs...)
 	default:
-		gdi.log(fmt.Sprintf("inject fieldName:%v->%v of %v pkgPath:%v", fieldName, field.Type(), vStruct.Type(), pkgPath))
+		gdi.log(msg, attrs...)
+		gdi.emitInject(vStruct, fieldName, field, pkgPath)
+	}
+}
+
+// injectFail 注入失败，InitE/DIE 中收集错误，否则打印日志或退出，optional 属性直接忽略
+func (gdi *GDIPool) injectFail(fieldName string, field reflect.Value, vStruct reflect.Value, pkgPath string, err error, exitOnError bool, optional bool) {
+	if optional {
+		gdi.log(fmt.Sprintf("ignore optional fieldName:%v->%v of %v pkgPath:%v", fieldName, field.Type(), vStruct.Type(), pkgPath))
+		return
+	}
+	if gdi.errs != nil {
+		ie := &InjectError{Type: vStruct.Type().String(), Field: fieldName, FieldType: field.Type().String(), PkgPath: pkgPath, Err: err}
+		gdi.error(ie.Error(), "event", eventError, "type", ie.Type, "field", fieldName, "fieldType", ie.FieldType, "pkgPath", pkgPath)
+		gdi.errs.add(ie)
+		return
+	}
+	if err != nil {
+		gdi.error(err.Error())
+		gdi.emitError(&InjectError{Type: vStruct.Type().String(), Field: fieldName, FieldType: field.Type().String(), PkgPath: pkgPath, Err: err})
+	}
+	if exitOnError {
+		gdi.injectLog(fieldName, field, vStruct, pkgPath, logLevelExit)
+	} else {
+		gdi.injectLog(fieldName, field, vStruct, pkgPath, logLevelError)
 	}
 }
 
@@ -415,6 +509,7 @@ func (gdi *GDIPool) build(v reflect.Value, exitOnError bool, buildForTest bool)
 	}
 	n := &node{}
 	n.name = v.Type().String()
+	n.pkgPath = v.Type().Elem().PkgPath()
 	gdi.addNode(n)
 	for i := 0; i < v.Elem().NumField(); i++ {
 		nf := &nodeItem{}
@@ -422,10 +517,45 @@ func (gdi *GDIPool) build(v reflect.Value, exitOnError bool, buildForTest bool)
 		field := v.Elem().Field(i)
 		pkgPath := v.Type().Elem().PkgPath()
 		fieldName := v.Type().Elem().Field(i).Name
-		nf.fieldName = fmt.Sprintf(`f%v#%v`, i, fieldName)
+		nf.index = i
+		nf.fieldName = fieldName
 		nf.fieldType = v.Type().Elem().Field(i).Type.String()
 		_ = pkgPath
-		if field.Kind() != reflect.Interface && field.Kind() != reflect.Ptr {
+		option := gdi.getInjectOption(v.Type().Elem().Field(i))
+		if option == injectSkip { // inject:"-"
+			continue
+		}
+		if key, ok := gdi.getTagAttr(v.Type().Elem().Field(i), "config"); ok && key != "" { // inject:"config:db.dsn;default:localhost"
+			if !field.CanSet() {
+				field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+			}
+			if err := gdi.injectConfig(field, v.Type().Elem().Field(i), key); err != nil {
+				gdi.injectFail(fieldName, field, v, pkgPath, err, exitOnError, option == injectOptional)
+				continue
+			}
+			gdi.log(fmt.Sprintf("inject config:%v fieldName:%v->%v of %v pkgPath:%v", key, fieldName, field.Type(), v.Type(), pkgPath))
+			continue
+		}
+		if target, ok := gdi.lazyFieldTarget(v.Type().Elem().Field(i)); ok { // gdi.Lazy[*T]、*gdi.Lazy[*T] 或 func() *T `inject:"lazy"` 第一次调用时才获取
+			if !field.CanSet() {
+				if gdi.ignorePrivate {
+					gdi.warn(fmt.Sprintf("\u001B[1;31mignore type:%v fieldName:%v of %v pkgPath:%v\u001B[0m", field.Type(), fieldName, v.Type(), pkgPath))
+					continue
+				}
+				field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+			}
+			if lazyInjected(field) {
+				continue
+			}
+			sf := v.Type().Elem().Field(i)
+			field.Set(newLazy(field.Type(), gdi.lazyResolver(target, v, fieldName, &sf)))
+			n.addEdge(&edge{from: v.Type().String(), index: i, field: fieldName, to: target.String()})
+			gdi.injectLog(fieldName, field, v, pkgPath, logLevelInfo)
+			continue
+		}
+		_, all := gdi.getTagAttr(v.Type().Elem().Field(i), "all")
+		all = all && (field.Kind() == reflect.Slice || field.Kind() == reflect.Map)
+		if field.Kind() != reflect.Interface && field.Kind() != reflect.Ptr && !all {
 			continue
 		}
 		if !field.CanSet() {
@@ -435,6 +565,17 @@ func (gdi *GDIPool) build(v reflect.Value, exitOnError bool, buildForTest bool)
 			}
 			field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
 		}
+		if all { // inject:"all" 注入所有实现
+			if !field.IsNil() {
+				continue
+			}
+			if err := gdi.injectAll(v, fieldName, field, n, i); err != nil {
+				gdi.injectFail(fieldName, field, v, pkgPath, err, exitOnError, option == injectOptional)
+				continue
+			}
+			gdi.injectLog(fieldName, field, v, pkgPath, logLevelInfo)
+			continue
+		}
 		if field.IsValid() && !field.IsNil() {
 			//TODO may be pannic
 			if field.Elem().Kind() == reflect.Struct {
@@ -455,49 +596,67 @@ func (gdi *GDIPool) build(v reflect.Value, exitOnError bool, buildForTest bool)
 			}
 			if value, ok := gdi.getByName(name); ok {
 				//n.addEdge(&edge{from: nf.fieldType, to: value.Type().String()})
-				n.addEdge(&edge{from: fmt.Sprintf(`"%v":f%v`, v.Type().String(), i), to: value.Type().String()})
+				n.addEdge(&edge{from: v.Type().String(), index: i, field: fieldName, to: value.Type().String()})
+				field.Set(value)
+				gdi.addDependency(v, fieldName, field)
+				gdi.injectLog(fieldName, field, v, pkgPath, logLevelInfo)
+				continue
+			} else {
+				if gdi.errs == nil && option != injectOptional {
+					gdi.panic(fmt.Sprintf("name:%v type:%v object not found", name, field.Type()))
+				}
+				gdi.injectFail(fieldName, field, v, pkgPath, fmt.Errorf("name:%v object not found", name), exitOnError, option == injectOptional)
+				continue
+			}
+		}
+		if pt, ok := gdi.prototypeTypeOf(field.Type(), v.Type().Elem().Field(i)); ok { // scope:prototype
+			if value, err := gdi.newInstance(pt); err == nil {
 				field.Set(value)
+				gdi.addDependency(v, fieldName, field)
+				n.addEdge(&edge{from: v.Type().String(), index: i, field: fieldName, to: value.Type().String()})
 				gdi.injectLog(fieldName, field, v, pkgPath, logLevelInfo)
 				continue
 			} else {
-				gdi.panic(fmt.Sprintf("name:%v type:%v object not found", name, field.Type()))
+				gdi.injectFail(fieldName, field, v, pkgPath, err, exitOnError, option == injectOptional)
+				continue
 			}
 		}
 		if field.Kind() == reflect.Interface { // interface
 			if !field.IsNil() {
 				continue
 			}
-			if im, err := gdi.getByInterface(field.Type(), fieldName, v, exitOnError, buildForTest); err == nil {
+			if im, handled, err := gdi.getQualified(field.Type(), v.Type().Elem().Field(i), exitOnError, buildForTest, gdi.autoCreate && option == injectDefault); handled { // inject:"impl:pkg.MySQLRepo" or inject:"qualifier:primary"
+				if err != nil {
+					gdi.injectFail(fieldName, field, v, pkgPath, err, exitOnError, option == injectOptional)
+					continue
+				}
 				field.Set(im)
+				gdi.addDependency(v, fieldName, field)
+				n.addEdge(&edge{from: v.Type().String(), index: i, field: fieldName, to: im.Type().String()})
+				gdi.injectLog(fieldName, field, v, pkgPath, logLevelInfo)
+				continue
+			}
+			if im, err := gdi.getByInterface(field.Type(), fieldName, v, exitOnError, buildForTest, gdi.autoCreate && option == injectDefault); err == nil {
+				field.Set(im)
+				gdi.addDependency(v, fieldName, field)
 				//n.addEdge(&edge{from: fmt.Sprintf("%v:f%v", nf.fieldType,i), to: im.Type().String()})
-				n.addEdge(&edge{from: fmt.Sprintf(`"%v":f%v`, v.Type().String(), i), to: im.Type().String()})
+				n.addEdge(&edge{from: v.Type().String(), index: i, field: fieldName, to: im.Type().String()})
 				gdi.injectLog(fieldName, field, v, pkgPath, logLevelInfo)
 				continue
 			} else {
-				if field.Type().String() != "interface {}" && field.Type().String() != "error" {
-					gdi.error(err.Error())
-					if exitOnError {
-						gdi.injectLog(fieldName, field, v, pkgPath, logLevelExit)
-					} else {
-						gdi.injectLog(fieldName, field, v, pkgPath, logLevelError)
-					}
-
+				if option == injectRequired || field.Type().String() != "interface {}" && field.Type().String() != "error" {
+					gdi.injectFail(fieldName, field, v, pkgPath, err, exitOnError, option == injectOptional)
 				} else {
 					gdi.warn(fmt.Sprintf("\u001B[1;31mignore type:%v fieldName:%v of %v pkgPath:%v\u001B[0m", field.Type(), fieldName, v.Type(), pkgPath))
-					continue
 				}
+				continue
 			}
-			if exitOnError {
-				gdi.injectLog(fieldName, field, v, pkgPath, logLevelExit)
-			} else {
-				gdi.injectLog(fieldName, field, v, pkgPath, logLevelError)
-			}
-			continue
 		}
 		if im, ok := gdi.get(field.Type()); ok { // by type
 			field.Set(im)
+			gdi.addDependency(v, fieldName, field)
 			//n.addEdge(&edge{from: nf.fieldType, to: im.Type().String()})
-			n.addEdge(&edge{from: fmt.Sprintf(`"%v":f%v`, v.Type().String(), i), to: im.Type().String()})
+			n.addEdge(&edge{from: v.Type().String(), index: i, field: fieldName, to: im.Type().String()})
 			//n.addFiled(nf)
 			gdi.injectLog(fieldName, field, v, pkgPath, logLevelInfo)
 			continue
@@ -507,28 +666,28 @@ func (gdi *GDIPool) build(v reflect.Value, exitOnError bool, buildForTest bool)
 				if fv, ok := gdi.typeToValuesForTest[field.Type()]; ok {
 					gdi.warn(fmt.Sprintf("inject For Test fieldName:%v->%v of %v pkgPath:%v", fieldName, field.Type(), v.Type(), pkgPath))
 					field.Set(fv)
+					gdi.ttvLocker.Unlock()
+					gdi.addDependency(v, fieldName, field)
 					continue
 				}
 				gdi.ttvLocker.Unlock()
 			}
-			if gdi.autoCreate {
+			if gdi.autoCreate && option == injectDefault {
 				value := reflect.New(field.Type().Elem())
 				field.Set(value)
+				gdi.addDependency(v, fieldName, field)
 				//n.addEdge(&edge{from: nf.fieldType, to: value.Type().String()})
-				n.addEdge(&edge{from: fmt.Sprintf(`"%v":f%v`, v.Type().String(), i), to: value.Type().String()})
+				n.addEdge(&edge{from: v.Type().String(), index: i, field: fieldName, to: value.Type().String()})
 				gdi.injectLog(fieldName, field, v, pkgPath, logLevelInfo)
-				gdi.warn(fmt.Sprintf("\u001B[1;35mautoCreate\u001B[0m type:%v fieldName:%v of %v", field.Type(), fieldName, v.Type()))
-				gdi.set(field.Type(), value.Interface())
+				gdi.warn(fmt.Sprintf("\u001B[1;35mautoCreate\u001B[0m type:%v fieldName:%v of %v", field.Type(), fieldName, v.Type()), "event", eventAutoCreate, "type", field.Type().String(), "field", fieldName, "owner", v.Type().String(), "pkgPath", pkgPath)
+				gdi.emitAutoCreate(AutoCreateEvent{Type: field.Type(), Owner: v.Type(), Field: fieldName})
+				gdi.setAutoCreated(field.Type(), value)
 				gdi.build(value, exitOnError, buildForTest)
 			}
 		}
 
 		if field.IsNil() {
-			if exitOnError {
-				gdi.injectLog(fieldName, field, v, pkgPath, logLevelExit)
-			} else {
-				gdi.injectLog(fieldName, field, v, pkgPath, logLevelError)
-			}
+			gdi.injectFail(fieldName, field, v, pkgPath, fmt.Errorf("type:%v not found", field.Type()), exitOnError, option == injectOptional)
 		}
 		//n.addFiled(nf)
 	}
@@ -580,12 +739,12 @@ func (gdi *GDIPool) Debug(isDebug bool) {
 	gdi.debug = isDebug
 }
 
-func Graph() string {
-	return globalGDI.Graph()
+func Graph(opts ...GraphOptions) string {
+	return globalGDI.Graph(opts...)
 }
 
-func SaveGraphToFile(fpath string) error {
-	return globalGDI.SaveGraphToFile(fpath)
+func SaveGraphToFile(fpath string, opts ...GraphOptions) error {
+	return globalGDI.SaveGraphToFile(fpath, opts...)
 }
 
 //Debug 是否开启调试信息
@@ -660,6 +819,9 @@ func (gdi *GDIPool) DIForTest(pointer interface{}) (e error) {
 			e = fmt.Errorf(fmt.Sprintf("%v", err))
 		}
 	}()
+	if gdi.isClosed() {
+		return errScopeClosed
+	}
 	var result reflect.Value
 	ftype := reflect.TypeOf(pointer)
 	if ftype.Kind() != reflect.Ptr {
@@ -676,6 +838,30 @@ func (gdi *GDIPool) DIForTest(pointer interface{}) (e error) {
 	return e
 }
 
+// DIE 与 DI 相同，但会收集所有注入失败的属性，合并成一个 MultiError 返回
+func (gdi *GDIPool) DIE(pointer interface{}) (e error) {
+	gdi.lock.Lock()
+	defer gdi.lock.Unlock()
+	ftype := reflect.TypeOf(pointer)
+	if ftype == nil || ftype.Kind() != reflect.Ptr {
+		return errors.New("(ERROR) pointer type require")
+	}
+	result := reflect.ValueOf(pointer)
+	if result.IsNil() {
+		return errors.New("(ERROR) pointer is null ")
+	}
+	gdi.errs = &errorCollector{onError: gdi.emitError}
+	defer func() {
+		if err := recover(); err != nil {
+			gdi.errs.add(fmt.Errorf("%v", err))
+		}
+		e = gdi.errs.err()
+		gdi.errs = nil
+	}()
+	gdi.build(result, false, false)
+	return
+}
+
 // DI 自动依懒注入
 func (gdi *GDIPool) DI(pointer interface{}) (e error) {
 	gdi.lock.Lock()
@@ -686,6 +872,9 @@ func (gdi *GDIPool) DI(pointer interface{}) (e error) {
 			e = fmt.Errorf(fmt.Sprintf("%v", err))
 		}
 	}()
+	if gdi.isClosed() {
+		return errScopeClosed
+	}
 	var result reflect.Value
 	ftype := reflect.TypeOf(pointer)
 	if ftype.Kind() != reflect.Ptr {
@@ -706,7 +895,7 @@ func (gdi *GDIPool) Invoke(t interface{}) (interface{}, error) {
 	if ftype.Kind() == reflect.Func {
 		var args []reflect.Value
 		for i := 0; i < ftype.NumIn(); i++ {
-			if v, ok := gdi.get(ftype.In(i)); ok {
+			if v, ok := gdi.resolve(ftype.In(i)); ok {
 				args = append(args, v)
 			} else {
 				gdi.warn(fmt.Sprintf("type '%v' not register", ftype))
@@ -749,7 +938,11 @@ func (gdi *GDIPool) Get(t interface{}) (value interface{}) {
 		result, ok = gdi.getByName(name)
 	} else {
 		ftype := reflect.TypeOf(t)
-		result, ok = gdi.get(ftype)
+		result, ok = gdi.resolve(ftype)
+	}
+	if !ok && gdi.isClosed() {
+		gdi.error(errScopeClosed.Error())
+		return nil
 	}
 	if !ok {
 		gdi.warn(fmt.Sprintf("can't found %v,Is gdi.Init() called?", t))
@@ -766,7 +959,7 @@ func (gdi *GDIPool) GetWithCheck(t interface{}) (value interface{}, ok bool) {
 		result, ok = gdi.getByName(name)
 	} else {
 		ftype := reflect.TypeOf(t)
-		result, ok = gdi.get(ftype)
+		result, ok = gdi.resolve(ftype)
 	}
 	if !ok {
 		return nil, false
@@ -776,12 +969,34 @@ func (gdi *GDIPool) GetWithCheck(t interface{}) (value interface{}, ok bool) {
 
 }
 
+type injectOption int
+
+const (
+	injectDefault  injectOption = iota
+	injectOptional              // inject:"optional" 找不到时保持nil，不自动创建
+	injectRequired              // inject:"required" 找不到时报错，不自动创建
+	injectSkip                  // inject:"-" 不注入
+)
+
+func (gdi *GDIPool) getInjectOption(f reflect.StructField) injectOption {
+	if _, ok := gdi.getTagAttr(f, "-"); ok {
+		return injectSkip
+	}
+	if _, ok := gdi.getTagAttr(f, "optional"); ok {
+		return injectOptional
+	}
+	if _, ok := gdi.getTagAttr(f, "required"); ok {
+		return injectRequired
+	}
+	return injectDefault
+}
+
 func (gdi *GDIPool) getTagAttr(f reflect.StructField, tagAttr string) (string, bool) {
 	if tag, ok := f.Tag.Lookup("inject"); ok {
 		m := make(map[string]string)
 		tags := strings.Split(tag, ";")
 		for _, t := range tags {
-			kvs := strings.Split(t, ":")
+			kvs := strings.SplitN(t, ":", 2)
 			if len(kvs) == 1 {
 				m[kvs[0]] = ""
 			}
@@ -797,10 +1012,17 @@ func (gdi *GDIPool) getTagAttr(f reflect.StructField, tagAttr string) (string, b
 
 func (gdi *GDIPool) get(t reflect.Type) (result reflect.Value, ok bool) {
 	gdi.ttvLocker.RLock()
-	defer gdi.ttvLocker.RUnlock()
+	if gdi.closed {
+		gdi.ttvLocker.RUnlock()
+		return reflect.Value{}, false
+	}
 	if result, ok = gdi.typeToValues[t]; !ok {
 		result, ok = gdi.typeToValuesReadOnly[t]
 	}
+	gdi.ttvLocker.RUnlock()
+	if !ok && gdi.parent != nil {
+		return gdi.getFromParent(t)
+	}
 	return
 }
 
@@ -813,7 +1035,15 @@ func (gdi *GDIPool) MapToImplement(pkgToFieldInteface interface{}, pkgImplement
 	return nil
 }
 
-func (gdi *GDIPool) getByInterface(i reflect.Type, fieldName string, v reflect.Value, exitOnError bool, buildForTest bool) (value reflect.Value, err error) {
+func (gdi *GDIPool) getByInterface(i reflect.Type, fieldName string, v reflect.Value, exitOnError bool, buildForTest bool, autoCreate bool) (value reflect.Value, err error) {
+	if value, ok := gdi.getDecorated(i); ok {
+		return value, nil
+	}
+	if st, ok := gdi.getScopedByInterface(i); ok {
+		if value, ok = gdi.get(st); ok {
+			return value, nil
+		}
+	}
 tag:
 	cnt := 0
 	var values []reflect.Value
@@ -837,7 +1067,7 @@ tag:
 		for _, v := range values {
 			msgs = append(msgs, fmt.Sprintf("%v", v.Type()))
 		}
-		msg := fmt.Sprintf("there is one more object impliment %v interface [%v].please use gdi.MapToImplement to set Interface->Implements.", i.Name(), strings.Join(msgs, ","))
+		msg := fmt.Sprintf("there is one more object impliment %v interface [%v].please use gdi.MapToImplement to set Interface->Implements or inject:\"all\" to inject all of them.", i.Name(), strings.Join(msgs, ","))
 		return reflect.Value{}, fmt.Errorf(msg)
 	}
 	bflag := false
@@ -852,10 +1082,11 @@ tag:
 		//  fmt.Sprintf("enter")
 		//}
 		if t.Implements(i) {
-			if gdi.autoCreate {
+			if autoCreate {
 				value = reflect.New(t.Elem())
-				gdi.warn(fmt.Sprintf("\u001B[1;35mautoCreate\u001B[0m  type:%v fieldName:%v of %v", t, fieldName, v.Type()))
-				gdi.set(t, value.Interface())
+				gdi.warn(fmt.Sprintf("\u001B[1;35mautoCreate\u001B[0m  type:%v fieldName:%v of %v", t, fieldName, v.Type()), "event", eventAutoCreate, "type", t.String(), "field", fieldName, "owner", v.Type().String())
+				gdi.emitAutoCreate(AutoCreateEvent{Type: t, Owner: v.Type(), Field: fieldName})
+				gdi.setAutoCreated(t, value)
 				gdi.build(value, exitOnError, buildForTest)
 				bflag = true
 
@@ -873,36 +1104,40 @@ tag:
 func (gdi *GDIPool) getByName(name string) (result reflect.Value, ok bool) {
 	gdi.ttvLocker.RLock()
 	defer gdi.ttvLocker.RUnlock()
+	if gdi.closed {
+		return reflect.Value{}, false
+	}
 	if result, ok = gdi.namesToValues[name]; !ok {
 		result, ok = gdi.namesToValuesReadOnly[name]
 	}
+	if !ok && gdi.parent != nil {
+		return gdi.parent.getByName(name)
+	}
 	return
 }
 
-func (gdi *GDIPool) log(msg string) {
+func (gdi *GDIPool) log(msg string, attrs ...interface{}) {
 	if gdi.debug {
-		colorGreen := "\033[1;32m"
-		colorNormal := "\033[0m"
-		consoleLog.Println(colorGreen + msg + colorNormal)
+		gdi.output(LevelInfo, "\033[1;32m", "", msg, attrs...)
 	}
 }
-func (gdi *GDIPool) warn(msg string) {
-	colorYellow := "\033[1;33m"
-	colorNormal := "\033[0m"
-	consoleLog.Println(fmt.Sprintf("%v%v%v", colorYellow, "WARNNING: "+msg, colorNormal))
+func (gdi *GDIPool) warn(msg string, attrs ...interface{}) {
+	gdi.output(LevelWarn, "\033[1;33m", "WARNNING: ", msg, attrs...)
 }
-func (gdi *GDIPool) error(msg string) {
-	colorRed := "\033[1;31m"
-	colorNormal := "\033[0m"
-	consoleLog.Println(fmt.Sprintf("%v%v%v", colorRed, "ERROR: "+msg, colorNormal))
+func (gdi *GDIPool) error(msg string, attrs ...interface{}) {
+	gdi.output(LevelError, "\033[1;31m", "ERROR: ", msg, attrs...)
 }
-func (gdi *GDIPool) panic(msg string) {
-	gdi.error(msg)
-	panic("")
+func (gdi *GDIPool) panic(msg string, attrs ...interface{}) {
+	gdi.error(msg, attrs...)
+	panic(msg)
 }
 
 func (gdi *GDIPool) create(fun interface{}) []reflect.Value {
+	start := time.Now()
 	values := reflect.ValueOf(fun).Call([]reflect.Value{})
+	if ft := reflect.TypeOf(fun); ft.NumOut() > 0 {
+		gdi.emitCreate(CreateEvent{Type: ft.Out(0), Creator: ft, Duration: time.Since(start)})
+	}
 	if len(values) == 0 {
 		gdi.panic(fmt.Sprintf("Dependency injector: func return value must be a pointer or a pointer with error, %v", reflect.TypeOf(fun)))
 
@@ -928,6 +1163,9 @@ func (gdi *GDIPool) setReadOnly(outType reflect.Type, f interface{}) {
 	if f != nil {
 		if reflect.TypeOf(f).Kind() == reflect.Func {
 			vals := gdi.create(f)
+			if len(vals) > 0 {
+				gdi.markProvenance(vals[0], ProvenanceConstructor)
+			}
 			if len(vals) == 1 {
 				gdi.typeToValuesReadOnly[outType] = vals[0]
 			} else if len(vals) == 2 && vals[1].Kind() == reflect.String {
@@ -936,7 +1174,8 @@ func (gdi *GDIPool) setReadOnly(outType reflect.Type, f interface{}) {
 				}
 				name := vals[1].Interface().(string)
 				gdi.namesToValuesReadOnly[name] = vals[0]
-				gdi.log(fmt.Sprintf("register by name, name:%v type:%v success", name, vals[0].Type()))
+				gdi.log(fmt.Sprintf("register by name, name:%v type:%v success", name, vals[0].Type()), "event", eventRegister, "name", name, "type", vals[0].Type().String())
+				gdi.emitRegister(RegisterEvent{Type: vals[0].Type(), Name: name, ReadOnly: true})
 				return
 			} else if len(vals) == 2 && vals[1].Type().Implements(reflect.TypeOf((*error)(nil)).Elem()) {
 				if vals[1].Interface() != nil {
@@ -944,10 +1183,13 @@ func (gdi *GDIPool) setReadOnly(outType reflect.Type, f interface{}) {
 				}
 				gdi.typeToValuesReadOnly[outType] = vals[0]
 			}
-			gdi.log(fmt.Sprintf("register by type, type:%v pkgPath:%v success", outType, outType.Elem().PkgPath()))
+			gdi.log(fmt.Sprintf("register by type, type:%v pkgPath:%v success", outType, outType.Elem().PkgPath()), "event", eventRegister, "type", outType.String(), "pkgPath", outType.Elem().PkgPath())
+			gdi.emitRegister(RegisterEvent{Type: outType, ReadOnly: true})
 		} else if reflect.TypeOf(f).Kind() == reflect.Ptr {
 			gdi.typeToValuesReadOnly[outType] = reflect.ValueOf(f)
-			gdi.log(fmt.Sprintf("register by type, type:%v pkgPath:%v success", outType, outType.Elem().PkgPath()))
+			gdi.markProvenance(reflect.ValueOf(f), ProvenancePointer)
+			gdi.log(fmt.Sprintf("register by type, type:%v pkgPath:%v success", outType, outType.Elem().PkgPath()), "event", eventRegister, "type", outType.String(), "pkgPath", outType.Elem().PkgPath())
+			gdi.emitRegister(RegisterEvent{Type: outType, ReadOnly: true})
 		} else {
 			//gdi.typeToValues[outType] = reflect.ValueOf(f)
 			gdi.panic(fmt.Sprintf("%v type not support ", reflect.TypeOf(f)))
@@ -973,6 +1215,9 @@ func (gdi *GDIPool) set(outType reflect.Type, f interface{}) {
 	if f != nil {
 		if reflect.TypeOf(f).Kind() == reflect.Func {
 			vals := gdi.create(f)
+			if len(vals) > 0 {
+				gdi.markProvenance(vals[0], ProvenanceConstructor)
+			}
 			if len(vals) == 1 {
 				gdi.typeToValues[outType] = vals[0]
 			} else if len(vals) == 2 && vals[1].Kind() == reflect.String {
@@ -981,7 +1226,8 @@ func (gdi *GDIPool) set(outType reflect.Type, f interface{}) {
 				}
 				name := vals[1].Interface().(string)
 				gdi.namesToValues[name] = vals[0]
-				gdi.log(fmt.Sprintf("register by name, name:%v type:%v success", name, vals[0].Type()))
+				gdi.log(fmt.Sprintf("register by name, name:%v type:%v success", name, vals[0].Type()), "event", eventRegister, "name", name, "type", vals[0].Type().String())
+				gdi.emitRegister(RegisterEvent{Type: vals[0].Type(), Name: name})
 				return
 			} else if len(vals) == 2 && vals[1].Type().Implements(reflect.TypeOf((*error)(nil)).Elem()) {
 				if vals[1].Interface() != nil {
@@ -989,10 +1235,13 @@ func (gdi *GDIPool) set(outType reflect.Type, f interface{}) {
 				}
 				gdi.typeToValues[outType] = vals[0]
 			}
-			gdi.log(fmt.Sprintf("register by type, type:%v pkgPath:%v success", outType, outType.Elem().PkgPath()))
+			gdi.log(fmt.Sprintf("register by type, type:%v pkgPath:%v success", outType, outType.Elem().PkgPath()), "event", eventRegister, "type", outType.String(), "pkgPath", outType.Elem().PkgPath())
+			gdi.emitRegister(RegisterEvent{Type: outType})
 		} else if reflect.TypeOf(f).Kind() == reflect.Ptr {
 			gdi.typeToValues[outType] = reflect.ValueOf(f)
-			gdi.log(fmt.Sprintf("register by type, type:%v PkgPath:%v success", outType, outType.Elem().PkgPath()))
+			gdi.markProvenance(reflect.ValueOf(f), ProvenancePointer)
+			gdi.log(fmt.Sprintf("register by type, type:%v PkgPath:%v success", outType, outType.Elem().PkgPath()), "event", eventRegister, "type", outType.String(), "pkgPath", outType.Elem().PkgPath())
+			gdi.emitRegister(RegisterEvent{Type: outType})
 		} else {
 			//gdi.typeToValues[outType] = reflect.ValueOf(f)
 			gdi.panic(fmt.Sprintf("%v type not support ", reflect.TypeOf(f)))
@@ -1032,21 +1281,22 @@ func (gdi *GDIPool) parsePoolFunc(f interface{}) (outType reflect.Type, e error)
 	return
 }
 
-func (gdi *GDIPool) SaveGraphToFile(fpath string) error {
-	return ioutil.WriteFile(fpath, []byte(gdi.Graph()), 0777)
-}
-
 func (gdi *GDIPool) PlaceHolder(objs ...interface{}) {
 	gdi.creatorLocker.Lock()
 	defer gdi.creatorLocker.Unlock()
 	for _, obj := range objs {
 		gdi.placeHolders[reflect.TypeOf(obj).String()] = obj
 	}
-	pkgName=""//reset pkgName
+	gdi.src.lock.Lock()
+	gdi.src.pkgName = "" //reset pkgName
+	gdi.src.lock.Unlock()
 }
 
 func (gdi *GDIPool) GetRestInfoByPatten(packagePatten string) (map[string]restInfo, error) {
-	if len(restMap) == 0 {
+	gdi.src.lock.RLock()
+	empty := len(gdi.src.restMap) == 0
+	gdi.src.lock.RUnlock()
+	if empty {
 		gdi.GetRouterInfoByPatten(packagePatten)
 
 	}
@@ -1054,7 +1304,9 @@ func (gdi *GDIPool) GetRestInfoByPatten(packagePatten string) (map[string]restIn
 	rest := make(map[string]restInfo)
 
 	regPatten := regexp.MustCompile(packagePatten)
-	for k, v := range restMap {
+	gdi.src.lock.RLock()
+	defer gdi.src.lock.RUnlock()
+	for k, v := range gdi.src.restMap {
 		if regPatten.MatchString(k) {
 			rest[k] = v
 		}
@@ -21,23 +21,28 @@ import (
 	"sync"
 )
 
-var sources map[string][]string = make(map[string][]string)
-var packSources map[string][]string = make(map[string][]string)
-var restMap map[string]restInfo = make(map[string]restInfo)
-
-var pkgName string
+// sourceCache 项目源码缓存，第一次使用时才通过 go list 加载
+type sourceCache struct {
+	once        sync.Once
+	lock        sync.RWMutex
+	sources     map[string][]string // 包路径 -> 源码
+	packSources map[string][]string // 相对目录 -> 源码
+	restMap     map[string]restInfo
+	pkgName     string
+}
 
-func init() {
-	sources = make(map[string][]string)
-	packSources = make(map[string][]string)
-	restMap = make(map[string]restInfo)
-	sources = getGoSources()
+func newSourceCache() *sourceCache {
+	return &sourceCache{
+		sources:     make(map[string][]string),
+		packSources: make(map[string][]string),
+		restMap:     make(map[string]restInfo),
+	}
 }
 
-func listFiles(fsys *embed.FS, fpath string, fsMap map[string][]string) error {
+func (gdi *GDIPool) listFiles(fsys *embed.FS, fpath string, fsMap map[string][]string) error {
 	files, err := fs.ReadDir(fsys, fpath)
 	if err != nil {
-		globalGDI.error(fmt.Sprintf("read dir error: %s", err.Error()))
+		gdi.error(fmt.Sprintf("read dir error: %s", err.Error()))
 		return err
 	}
 
@@ -45,9 +50,9 @@ func listFiles(fsys *embed.FS, fpath string, fsMap map[string][]string) error {
 		if file.IsDir() {
 			//fmt.Printf("Directory: %s\n", file.Name())
 			if fpath != "." {
-				err = listFiles(fsys, fpath+"/"+file.Name(), fsMap)
+				err = gdi.listFiles(fsys, fpath+"/"+file.Name(), fsMap)
 			} else {
-				err = listFiles(fsys, file.Name(), fsMap)
+				err = gdi.listFiles(fsys, file.Name(), fsMap)
 			}
 			if err != nil {
 				return err
@@ -127,30 +132,56 @@ func GetAppModuleName() string { //TODO：通过比较包路径，获取包名
 }
 
 func (gdi *GDIPool) GetAppModuleName() string { //TODO：通过比较包路径，获取包名，不一定准确
-	if len(globalGDI.placeHolders) == 0 {
-		gdi.error("you must register at least three placeholder,you can call gdi.GenGDIRegisterFile(true) to register")
-		return ""
-	}
-	if !strings.Contains(pkgName, " ") && pkgName != "" {
-		return pkgName
-	}
+	gdi.creatorLocker.RLock()
 	var pkgPaths []string
-	for _, v := range globalGDI.placeHolders {
+	for _, v := range gdi.placeHolders {
 		if reflect.TypeOf(v).Elem().PkgPath() != "" {
 			pkgPaths = append(pkgPaths, reflect.TypeOf(v).Elem().PkgPath())
 		}
 	}
-	pkgName = strings.TrimSuffix(longestCommonPrefix(pkgPaths), "/")
-	return pkgName
+	placeHolders := len(gdi.placeHolders)
+	gdi.creatorLocker.RUnlock()
+	if placeHolders == 0 {
+		gdi.error("you must register at least three placeholder,you can call gdi.GenGDIRegisterFile(true) to register")
+		return ""
+	}
+	gdi.src.once.Do(gdi.loadGoSources) // 第一次调用时通过 go list 获取模块名，没有 go 命令时根据占位结构体的包路径推断
+	gdi.src.lock.Lock()
+	defer gdi.src.lock.Unlock()
+	if !strings.Contains(gdi.src.pkgName, " ") && gdi.src.pkgName != "" {
+		return gdi.src.pkgName
+	}
+	gdi.src.pkgName = strings.TrimSuffix(longestCommonPrefix(pkgPaths), "/")
+	return gdi.src.pkgName
 }
 
-func getGoSources() map[string][]string {
+// goSources 获取项目中各个包的源码，第一次调用时加载
+func (gdi *GDIPool) goSources() map[string][]string {
+	gdi.src.once.Do(gdi.loadGoSources)
+	gdi.src.lock.RLock()
+	defer gdi.src.lock.RUnlock()
+	return gdi.src.sources
+}
+
+// packSources 获取项目中各个目录的源码，没有源码时从 embed.FS 中读取
+func (gdi *GDIPool) packSources() map[string][]string {
+	gdi.src.once.Do(gdi.loadGoSources)
+	gdi.src.lock.Lock()
+	defer gdi.src.lock.Unlock()
+	if len(gdi.src.packSources) == 0 && gdi.fs != nil {
+		gdi.listFiles(gdi.fs, ".", gdi.src.packSources)
+	}
+	return gdi.src.packSources
+}
+
+func (gdi *GDIPool) loadGoSources() {
 	packagePath := runCmd("go", "list", "-f", "{{.Module}}", "./...")
 	packagePath = strings.TrimSpace(strings.Split(packagePath, "\n")[0])
-	pkgName = packagePath
+	gdi.src.lock.Lock()
+	defer gdi.src.lock.Unlock()
+	gdi.src.pkgName = packagePath
 	packages := getAllPackages()
 
-	goFiles := make(map[string][]string)
 	baseDir := strings.TrimSpace(getDir())
 	if !strings.HasPrefix(baseDir, "/") {
 		baseDir, _ = os.Getwd()
@@ -182,21 +213,19 @@ func getGoSources() map[string][]string {
 			}
 
 		}
-		packSources[strings.Trim(dir, "/")] = orginGors
-		goFiles[p] = orginGors
+		gdi.src.packSources[strings.Trim(dir, "/")] = orginGors
+		gdi.src.sources[p] = orginGors
 
 	}
 
-	return goFiles
-
 }
 
-func getImportSource() map[string][]string {
+func (gdi *GDIPool) getImportSource() map[string][]string {
 	goFiles := make(map[string][]string)
 	reg := regexp.MustCompile(`package\s+main\s*$`)
 	comment := regexp.MustCompile(`/\*{1,2}[\s\S]*?\*/|//[\s\S]*?\n`) //remove comment
 	regBrackets := regexp.MustCompile("`[^`]+?`|{[^{|}]*}")           //remove {}
-	for p, files := range sources {
+	for p, files := range gdi.goSources() {
 		var gos []string
 		for _, source := range files {
 			source = comment.ReplaceAllString(source, "")
@@ -270,8 +299,13 @@ func compareVersion(version1, version2 string) int {
 }
 
 func genDependency() string {
-	packages := getImportSource()
-	reg := regexp.MustCompile(`type\s+([A-Z]\w+)\s+struct`)
+	return globalGDI.genDependency()
+}
+
+func (gdi *GDIPool) genDependency() string {
+	packages := gdi.getImportSource()
+	rawSources := gdi.goSources()
+	reg := genStructReg
 
 	var aliasPack []string
 	var allPacks []string
@@ -281,6 +315,7 @@ func genDependency() string {
 	}
 	sort.Strings(allPacks)
 	var regFuncs []string
+	var registerCalls []string
 	index := 0
 	for _, p := range allPacks {
 		sources := packages[p]
@@ -305,7 +340,16 @@ func genDependency() string {
 		if bflag && p != "." {
 			aliasPack = append(aliasPack, fmt.Sprintf(`p%v "%v"`, index, p))
 		}
+		if !genRegisterEnabled(rawSources[p]) {
+			continue
+		}
+		if p == "." {
+			registerCalls = append(registerCalls, genRegisterCalls(sources, "", genRegisteredNames(rawSources[p]))...)
+		} else if bflag {
+			registerCalls = append(registerCalls, genRegisterCalls(sources, fmt.Sprintf("p%v.", index), genRegisteredNames(rawSources[p]))...)
+		}
 	}
+	regFuncs = append(regFuncs, registerCalls...)
 
 	tpl := `package main
 /*
@@ -366,7 +410,7 @@ func init() {
 	registerFun := strings.Join(regFuncs, "\n")
 
 	var ps []string
-	for p, _ := range packSources {
+	for p, _ := range gdi.packSources() {
 		ps = append(ps, p)
 	}
 	pss := strings.Join(ps, " ")
@@ -377,6 +421,149 @@ func init() {
 
 }
 
+var (
+	genStructReg      = regexp.MustCompile(`type\s+([A-Z]\w+)\s+struct`)
+	genConstructorReg = regexp.MustCompile(`(?m)^func\s+(New\w*)\s*\([^()]*\)\s*(?:\*([A-Z]\w*)|\(\s*\*([A-Z]\w*)\s*,\s*error\s*\))\s*$`)
+	genBindReg        = regexp.MustCompile(`var\s+_\s+([A-Z]\w*)\s*=\s*\(\*([A-Z]\w*)\)\(nil\)`)
+	genMarkerReg      = regexp.MustCompile(`(?m)^\s*//\s*gdi:register\s*$`)
+)
+
+// genRegisterEnabled 包中有源码文件包含 //gdi:register 注释时才生成注册代码
+func genRegisterEnabled(sources []string) bool {
+	for _, source := range sources {
+		if genMarkerReg.MatchString(source) {
+			return true
+		}
+	}
+	return false
+}
+
+// genRegisteredNames 包中已经手动注册(Register/RegisterReadOnly/Bind 等)的结构体、构造函数及接口名称，按语法树解析，支持跨行的调用
+func genRegisteredNames(sources []string) map[string]bool {
+	names := make(map[string]bool)
+	for _, source := range sources {
+		f, _ := parser.ParseFile(token.NewFileSet(), "", source, 0) // 语法错误时仍然使用已经解析的部分
+		if f == nil {
+			continue
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			var fn string
+			switch fun := call.Fun.(type) {
+			case *ast.SelectorExpr:
+				fn = fun.Sel.Name
+			case *ast.Ident:
+				fn = fun.Name
+			}
+			switch {
+			case fn == "Bind" || fn == "BindQualified":
+				if len(call.Args) > 0 {
+					if name, ok := genNilPointerType(call.Args[0]); ok {
+						names[name] = true
+					}
+				}
+			case strings.HasPrefix(fn, "Register"):
+				for _, arg := range call.Args {
+					switch a := arg.(type) {
+					case *ast.UnaryExpr: // &X{}
+						if lit, ok := a.X.(*ast.CompositeLit); ok && a.Op == token.AND {
+							if id, ok := lit.Type.(*ast.Ident); ok {
+								names[id.Name] = true
+							}
+						}
+					case *ast.Ident: // NewX
+						names[a.Name] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	return names
+}
+
+// genNilPointerType 解析 (*I)(nil) 中的类型名 I
+func genNilPointerType(expr ast.Expr) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	paren, ok := call.Fun.(*ast.ParenExpr)
+	if !ok {
+		return "", false
+	}
+	star, ok := paren.X.(*ast.StarExpr)
+	if !ok {
+		return "", false
+	}
+	id, ok := star.X.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	return id.Name, true
+}
+
+// genRegisterCalls 根据包的源码(已去掉注释及函数体)生成注册代码：有构造函数 func NewX(...) *X 或 func NewX(...) (*X, error) 的结构体注册构造函数，
+// 同一结构体有多个构造函数时只使用 NewX，否则注册结构体指针；包中只有一个实现通过 var _ I = (*T)(nil) 声明实现接口 I 时生成 Bind，
+// registered 中的结构体、构造函数及接口已经手动注册，不再生成
+func genRegisterCalls(sources []string, alias string, registered map[string]bool) []string {
+	var structs []string
+	isStruct := make(map[string]bool)
+	constructors := make(map[string][]string)
+	impls := make(map[string][]string)
+	for _, source := range sources {
+		for _, m := range genStructReg.FindAllStringSubmatch(source, -1) {
+			if !isStruct[m[1]] {
+				isStruct[m[1]] = true
+				structs = append(structs, m[1])
+			}
+		}
+		for _, m := range genConstructorReg.FindAllStringSubmatch(source, -1) {
+			out := m[2] + m[3]
+			constructors[out] = append(constructors[out], m[1])
+		}
+		for _, m := range genBindReg.FindAllStringSubmatch(source, -1) {
+			impls[m[1]] = append(impls[m[1]], m[2])
+		}
+	}
+	sort.Strings(structs)
+	var calls []string
+	for _, name := range structs {
+		constructor := ""
+		if fns := constructors[name]; len(fns) == 1 {
+			constructor = fns[0]
+		} else {
+			for _, fn := range fns {
+				if fn == "New"+name {
+					constructor = fn
+				}
+			}
+		}
+		if registered[name] || registered[constructor] {
+			continue
+		}
+		if constructor != "" {
+			calls = append(calls, fmt.Sprintf("gdi.Register(%v%v)", alias, constructor))
+		} else {
+			calls = append(calls, fmt.Sprintf("gdi.Register(&%v%v{})", alias, name))
+		}
+	}
+	var ifaces []string
+	for iface := range impls {
+		ifaces = append(ifaces, iface)
+	}
+	sort.Strings(ifaces)
+	for _, iface := range ifaces {
+		if len(impls[iface]) == 1 && isStruct[impls[iface][0]] && !registered[iface] {
+			calls = append(calls, fmt.Sprintf("gdi.Bind((*%v%v)(nil), (*%v%v)(nil))", alias, iface, alias, impls[iface][0]))
+		}
+	}
+	return calls
+}
+
 func GenGDIRegisterFile(override bool) {
 	globalGDI.GenGDIRegisterFile(override)
 }
@@ -393,10 +580,10 @@ func getCurrentAbPathByCaller(skip int) string {
 func (gdi *GDIPool) GenGDIRegisterFile(override bool) {
 	fn := getCurrentAbPathByCaller(3) + "/gdi_gen.go"
 	if _, err := os.Stat(fn); err != nil {
-		ioutil.WriteFile(fn, []byte(genDependency()), 0755)
+		ioutil.WriteFile(fn, []byte(gdi.genDependency()), 0755)
 	} else {
 		if override {
-			content := genDependency()
+			content := gdi.genDependency()
 			if strings.Contains(content, "gdi.PlaceHolder") { //如果不存在自动导入，没有必要覆盖
 				ioutil.WriteFile(fn, []byte(content), 0755)
 			}
@@ -503,7 +690,7 @@ var regexMiddlewarePrefix = regexp.MustCompile(`(?i)^\s*@middleware`)
 var regexDescriptionPrefix = regexp.MustCompile(`(?i)^\s*@description`)
 var regexRouterPrefix = regexp.MustCompile(`(?i)^\s*@router`)
 
-func parseRouterInfo(sourceCode string, pkgPath string) ([]RouterInfo, error) {
+func (gdi *GDIPool) parseRouterInfo(sourceCode string, pkgPath string) ([]RouterInfo, error) {
 	//trim empty line
 	lines := strings.Split(sourceCode, "\n")
 	var newLines []string
@@ -522,6 +709,9 @@ func parseRouterInfo(sourceCode string, pkgPath string) ([]RouterInfo, error) {
 	var routerInfos []RouterInfo
 	var currentRouterInfo RouterInfo
 	var rest restInfo
+	gdi.src.lock.Lock()
+	defer gdi.src.lock.Unlock()
+	pkgName := gdi.src.pkgName
 
 	for _, decl := range f.Decls {
 		switch d := decl.(type) {
@@ -554,7 +744,7 @@ func parseRouterInfo(sourceCode string, pkgPath string) ([]RouterInfo, error) {
 						rest.Controller = ts.Name.Name
 						rest.PkgPath = pkgPath
 						rest.PkgName = pkgName
-						restMap[fmt.Sprintf("%v.%v", pkgPath, rest.Controller)] = rest
+						gdi.src.restMap[fmt.Sprintf("%v.%v", pkgPath, rest.Controller)] = rest
 						_ = structType
 					}
 				}
@@ -749,14 +939,16 @@ func (gdi *GDIPool) genRouter(packageName string) ([]RouterInfo, error) {
 			continue
 		}
 		//fmt.Println(string(byteContents))
-		infos, err := parseRouterInfo(string(byteContents), packageName)
+		infos, err := gdi.parseRouterInfo(string(byteContents), packageName)
 		if err != nil {
 			return infos, err
 		}
 		routerInfos = append(routerInfos, infos...)
 	}
 	// build router info
-	for k, rest := range restMap {
+	gdi.src.lock.RLock()
+	defer gdi.src.lock.RUnlock()
+	for k, rest := range gdi.src.restMap {
 		for i, route := range routerInfos {
 			if route.Controller == k {
 				if route.Uri == "" {
@@ -776,7 +968,7 @@ func (gdi *GDIPool) GetRouterInfoByPatten(packagePatten string) (map[string]Rout
 	var routerInfoMap = make(map[string]RouterInfo)
 	packageNames := make(map[string]string)
 	regPatten := regexp.MustCompile(packagePatten)
-	for k, _ := range packSources {
+	for k, _ := range gdi.packSources() {
 		if regPatten.MatchString(k) {
 			packageNames[k] = k
 		}
@@ -822,10 +1014,6 @@ func (gdi *GDIPool) SetEmbedFs(fs *embed.FS) {
 		return
 	}
 	gdi.fs = fs
-	if len(packSources) == 0 {
-		packSources = make(map[string][]string)
-		listFiles(fs, ".", packSources)
-	}
 }
 
 func (gdi *GDIPool) getFileConent(filePath string) ([]byte, error) {
@@ -2,6 +2,7 @@ package gdi
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -26,3 +27,197 @@ func Test_parseMiddlewareAnnotations(t *testing.T) {
 
 
 }
+
+type isoController struct{}
+
+func TestPoolIsolation(t *testing.T) {
+	p1 := NewGDIPool()
+	p2 := NewGDIPool()
+	p1.Debug(false)
+	p2.Debug(false)
+	p1.PlaceHolder((*isoController)(nil))
+	if p1.GetAppModuleName() != "github.com/sjqzhang/gdi" {
+		t.Fatalf("unexpected module name %v", p1.GetAppModuleName())
+	}
+	if p2.GetAppModuleName() != "" {
+		t.Fatal("placeholders should not be shared between pools")
+	}
+	source := `package ctrl
+// @router /api/user
+type UserController struct{}
+`
+	if _, err := p1.parseRouterInfo(source, "ctrl"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p1.src.restMap["ctrl.UserController"]; !ok {
+		t.Fatal("rest info should be parsed")
+	}
+	if len(p2.src.restMap) != 0 {
+		t.Fatal("rest info should not be shared between pools")
+	}
+}
+
+func TestGenRegisterCalls(t *testing.T) {
+	sources := []string{`package repo
+type Repo interface
+type MySQLRepo struct
+var _ Repo = (*MySQLRepo)(nil)
+type Cache struct
+func NewCache(cfg *Config) (*Cache, error)
+type Config struct
+func NewConfig() *Config
+func NewConfigFromEnv() *Config
+type Client struct
+func NewClient() *Client
+func NewClientWithTimeout(d time.Duration) *Client
+func (c *Client) NewSession() *Session
+type Reader interface
+var _ Reader = (*MySQLRepo)(nil)
+var _ Reader = (*Cache)(nil)
+`}
+	expects := []string{
+		"gdi.Register(p1.NewCache)",
+		"gdi.Register(p1.NewClient)",
+		"gdi.Register(p1.NewConfig)",
+		"gdi.Register(&p1.MySQLRepo{})",
+		"gdi.Bind((*p1.Repo)(nil), (*p1.MySQLRepo)(nil))",
+	}
+	calls := genRegisterCalls(sources, "p1.", nil)
+	if strings.Join(calls, "\n") != strings.Join(expects, "\n") {
+		t.Fatalf("unexpected calls:\n%v", strings.Join(calls, "\n"))
+	}
+}
+
+func TestGenDependencyRegister(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.src.once.Do(func() {})
+	gp.src.sources["github.com/app/repo"] = []string{`package repo
+
+//gdi:register
+
+type Repo interface {
+	Find(id int) string
+}
+
+type MySQLRepo struct {
+	DB *Config
+}
+
+var _ Repo = (*MySQLRepo)(nil)
+
+type Config struct{}
+
+func NewConfig() *Config {
+	return &Config{}
+}
+
+type Cache struct{}
+
+type Client struct{}
+
+func NewClient() *Client {
+	return &Client{}
+}
+
+func init() {
+	gdi.Register(
+		&Cache{},
+		NewClient,
+	)
+}
+`}
+	gp.src.sources["github.com/app/service"] = []string{`package service
+
+type UserService struct{}
+`}
+	content := gp.genDependency()
+	for _, line := range []string{
+		`p1 "github.com/app/repo"`,
+		`p2 "github.com/app/service"`,
+		"gdi.PlaceHolder((*p1.Cache)(nil))",
+		"gdi.PlaceHolder((*p2.UserService)(nil))",
+		"gdi.Register(p1.NewConfig)",
+		"gdi.Register(&p1.MySQLRepo{})",
+		"gdi.Bind((*p1.Repo)(nil), (*p1.MySQLRepo)(nil))",
+	} {
+		if !strings.Contains(content, line) {
+			t.Fatalf("%v not generated:\n%v", line, content)
+		}
+	}
+	for _, line := range []string{"gdi.Register(&p1.Cache{})", "gdi.Register(p1.NewClient)", "gdi.Register(&p1.Client{})", "p2.UserService{}"} {
+		if strings.Contains(content, line) {
+			t.Fatalf("%v should not be generated:\n%v", line, content)
+		}
+	}
+	if strings.Count(content, "gdi.Register(") != 2 {
+		t.Fatalf("unexpected register calls:\n%v", content)
+	}
+}
+
+type GenRtRepo interface{ Find() string }
+type GenRtMySQLRepo struct{ Config *GenRtConfig }
+type GenRtConfig struct{ DSN string }
+type GenRtService struct{ repo GenRtRepo }
+
+var _ GenRtRepo = (*GenRtMySQLRepo)(nil)
+
+func (r *GenRtMySQLRepo) Find() string { return "mysql:" + r.Config.DSN }
+
+func NewGenRtConfig() (*GenRtConfig, error) { return &GenRtConfig{DSN: "dsn"}, nil }
+
+func NewGenRtService(repo GenRtRepo) *GenRtService { return &GenRtService{repo: repo} }
+
+func TestGenDependencyInit(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.src.once.Do(func() {})
+	gp.src.sources["github.com/app/rt"] = []string{`package rt
+
+//gdi:register
+
+type GenRtRepo interface{ Find() string }
+type GenRtMySQLRepo struct{ Config *GenRtConfig }
+type GenRtConfig struct{ DSN string }
+type GenRtService struct{ repo GenRtRepo }
+
+var _ GenRtRepo = (*GenRtMySQLRepo)(nil)
+
+func (r *GenRtMySQLRepo) Find() string { return "mysql:" + r.Config.DSN }
+
+func NewGenRtConfig() (*GenRtConfig, error) { return &GenRtConfig{DSN: "dsn"}, nil }
+
+func NewGenRtService(repo GenRtRepo) *GenRtService { return &GenRtService{repo: repo} }
+`}
+	content := gp.genDependency()
+	// 执行生成的注册代码(p1 为本包)
+	calls := map[string]func(){
+		"gdi.Register(p1.NewGenRtConfig)":                           func() { gp.Register(NewGenRtConfig) },
+		"gdi.Register(&p1.GenRtMySQLRepo{})":                        func() { gp.Register(&GenRtMySQLRepo{}) },
+		"gdi.Register(p1.NewGenRtService)":                          func() { gp.Register(NewGenRtService) },
+		"gdi.Bind((*p1.GenRtRepo)(nil), (*p1.GenRtMySQLRepo)(nil))": func() { gp.Bind((*GenRtRepo)(nil), (*GenRtMySQLRepo)(nil)) },
+	}
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "gdi.Register(") || strings.HasPrefix(line, "gdi.Bind(") {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != len(calls) {
+		t.Fatalf("unexpected generated calls %v", lines)
+	}
+	for _, line := range lines {
+		call, ok := calls[line]
+		if !ok {
+			t.Fatalf("unexpected generated call %v", line)
+		}
+		call()
+	}
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	if svc := gp.Get(&GenRtService{}).(*GenRtService); svc.repo.Find() != "mysql:dsn" {
+		t.Fatalf("unexpected service %+v", svc)
+	}
+}
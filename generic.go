@@ -0,0 +1,133 @@
+package gdi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Resolve 按类型从容器中获取对象，T 可以是指针类型或接口类型，pool 为 nil 时使用全局容器
+func Resolve[T any](pool *GDIPool) (T, error) {
+	var zero T
+	if pool == nil {
+		pool = globalGDI
+	}
+	if pool.isClosed() {
+		return zero, errScopeClosed
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if pool.isPrototype(t) { // 原型创建失败(如循环依赖)时返回原因
+		value, err := pool.newInstance(t)
+		if err != nil {
+			return zero, err
+		}
+		return value.Interface().(T), nil
+	}
+	value, ok := pool.get(t)
+	if !ok && t.Kind() == reflect.Interface {
+		var err error
+		if value, err = pool.resolveInterface(t); err != nil {
+			return zero, err
+		}
+		ok = true
+	}
+	if !ok {
+		return zero, fmt.Errorf("(ERROR) type %v not found, Is gdi.Init() called?", t)
+	}
+	result, ok := value.Interface().(T)
+	if !ok {
+		return zero, fmt.Errorf("(ERROR) %v can't convert to %v", value.Type(), t)
+	}
+	return result, nil
+}
+
+// MustResolve 与 Resolve 相同，找不到时panic
+func MustResolve[T any](pool *GDIPool) T {
+	result, err := Resolve[T](pool)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// ResolveNamed 按名称从容器中获取对象，pool 为 nil 时使用全局容器
+func ResolveNamed[T any](pool *GDIPool, name string) (T, error) {
+	var zero T
+	if pool == nil {
+		pool = globalGDI
+	}
+	value, ok := pool.getByName(name)
+	if !ok {
+		return zero, fmt.Errorf("(ERROR) name:%v object not found, Is gdi.Init() called?", name)
+	}
+	result, ok := value.Interface().(T)
+	if !ok {
+		return zero, fmt.Errorf("(ERROR) name:%v type:%v can't convert to %v", name, value.Type(), reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return result, nil
+}
+
+// Provide 注册没有参数的构造函数，编译时检查返回值类型，T 必须是结构体指针，构造函数的返回值按属性注入依赖
+// Example：gdi.Provide(nil, NewUserService)，nil 表示全局容器
+func Provide[T any](pool *GDIPool, constructor func() T) error {
+	return provide(pool, reflect.TypeOf((*T)(nil)).Elem(), constructor)
+}
+
+// ProvideFunc 注册没有参数且可能返回错误的构造函数，构造函数在 Init 时调用，返回的错误由 InitE 返回
+// Example：gdi.ProvideFunc(nil, func() (*Config, error) { return LoadConfig() })
+func ProvideFunc[T any](pool *GDIPool, constructor func() (T, error)) error {
+	return provide(pool, reflect.TypeOf((*T)(nil)).Elem(), constructor)
+}
+
+// provide 检查 t 是否为结构体指针及是否重复注册后注册构造函数
+func provide(pool *GDIPool, t reflect.Type, constructor interface{}) error {
+	if pool == nil {
+		pool = globalGDI
+	}
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("(ERROR) %v is not a struct pointer, use gdi.Bind to map an interface to its implementation", t)
+	}
+	if reflect.ValueOf(constructor).IsNil() {
+		return fmt.Errorf("(ERROR) constructor of %v is nil", t)
+	}
+	pool.creatorLocker.RLock()
+	_, exists := pool.creator[t]
+	pool.creatorLocker.RUnlock()
+	if _, ok := pool.get(t); ok || exists || pool.isPrototype(t) {
+		return fmt.Errorf("(ERROR) double register %v", t)
+	}
+	pool.Register(constructor)
+	return nil
+}
+
+// resolveInterface 从容器中查找接口的唯一实现
+func (gdi *GDIPool) resolveInterface(i reflect.Type) (reflect.Value, error) {
+	if value, ok := gdi.getDecorated(i); ok {
+		return value, nil
+	}
+	if t, ok := gdi.getQualifiedType(i, ""); ok {
+		if value, ok := gdi.resolve(t); ok {
+			return value, nil
+		}
+	}
+	var values []reflect.Value
+	for t, v := range gdi.all() {
+		if t.Implements(i) {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 1 {
+		return values[0], nil
+	}
+	if len(values) > 1 {
+		var msgs []string
+		for _, v := range values {
+			msgs = append(msgs, v.Type().String())
+		}
+		return reflect.Value{}, fmt.Errorf("(ERROR) there is one more object impliment %v interface [%v]", i, strings.Join(msgs, ","))
+	}
+	if t, ok := gdi.getPrototypeByInterface(i); ok {
+		return gdi.newInstance(t)
+	}
+	return reflect.Value{}, fmt.Errorf("(ERROR) interface type:%v not found, Is gdi.Init() called?", i)
+}
@@ -0,0 +1,91 @@
+package gdi
+
+import (
+	"errors"
+	"testing"
+)
+
+type genGreeter interface {
+	Greet() string
+}
+
+type genHello struct {
+	Word *string `inject:"name:word"`
+}
+
+func (h *genHello) Greet() string {
+	return "hello " + *h.Word
+}
+
+type genApp struct {
+	G genGreeter
+}
+
+type genTyped struct{ Name string }
+
+func TestGeneric(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func() (*string, string) {
+		word := "gdi"
+		return &word, "word"
+	})
+	if err := Provide(gp, func() *genHello {
+		return &genHello{}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ProvideFunc(gp, func() (*genHello, error) { return nil, nil }); err == nil {
+		t.Fatal("Provide should reject double register")
+	}
+	if err := ProvideFunc[genGreeter](gp, func() (genGreeter, error) { return &genHello{}, nil }); err == nil {
+		t.Fatal("Provide should reject interface type")
+	}
+	if err := Provide[genGreeter](gp, func() genGreeter { return &genHello{} }); err == nil {
+		t.Fatal("Provide should reject interface type")
+	}
+	if err := Provide[*genApp](gp, nil); err == nil {
+		t.Fatal("Provide should reject nil constructor")
+	}
+	if err := ProvideFunc(gp, func() (*genTyped, error) { return &genTyped{Name: "typed"}, nil }); err != nil {
+		t.Fatal(err)
+	}
+	gp.Init()
+
+	if MustResolve[*genTyped](gp).Name != "typed" {
+		t.Fatal("ProvideFunc should register the constructor")
+	}
+	h, err := Resolve[*genHello](gp)
+	if err != nil || h.Greet() != "hello gdi" {
+		t.Fatalf("Resolve pointer fail %v", err)
+	}
+	g := MustResolve[genGreeter](gp)
+	if g.Greet() != "hello gdi" {
+		t.Fatal("Resolve interface fail")
+	}
+	word, err := ResolveNamed[*string](gp, "word")
+	if err != nil || *word != "gdi" {
+		t.Fatalf("ResolveNamed fail %v", err)
+	}
+	if _, err := Resolve[*genApp](gp); err == nil {
+		t.Fatal("Resolve should return error when not found")
+	}
+	if v, ok := gp.GetWithCheck((*genHello)(nil)); !ok || v.(*genHello) != h {
+		t.Fatal("GetWithCheck fail")
+	}
+}
+
+type genFailed struct{}
+
+func TestProvideFuncError(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	if err := ProvideFunc(gp, func() (*genFailed, error) { return nil, errors.New("boom") }); err != nil {
+		t.Fatal(err)
+	}
+	err := gp.InitE()
+	var ce *CreateError
+	if !errors.As(err, &ce) || ce.Type != "*gdi.genFailed" || ce.Err.Error() != "boom" {
+		t.Fatalf("constructor error should be returned by InitE, got %v", err)
+	}
+}
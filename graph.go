@@ -1,8 +1,6 @@
 package gdi
 
 import (
-	"fmt"
-	"strings"
 	"sync"
 )
 
@@ -16,7 +14,8 @@ import (
 //}
 
 type node struct {
-	name   string
+	name    string
+	pkgPath string
 	fields []*nodeItem
 	edges  []*edge
 	//fieldMap map[string]struct{}
@@ -25,11 +24,14 @@ type node struct {
 }
 
 type edge struct {
-	from string
-	to   string
+	from  string // 属性所在的类型
+	index int    // 属性的序号
+	field string
+	to    string
 }
 
 type nodeItem struct {
+	index     int
 	fieldName string
 	fieldType string
 }
@@ -63,41 +65,8 @@ func (gdi *GDIPool) addNode(n *node) {
 	}
 }
 
-func (gdi *GDIPool) Graph() string {
-	var gs []string
-	/*
-		"node0" [
-		label = "<f0> 0x10ba8| <f1>"
-		shape = "record"
-		];
-	*/
-	nodeTpl := `
-   "%v" [
-     label = <%v>
-     shape = "none"
- ]
-`
-	gTpl := `
-digraph { 
-
-rankdir=LR;
-  %v
-}
-`
-	for _, n := range gdi.g.nodes {
-		var fields []string
-		fields = append(fields, fmt.Sprintf(`<table BORDER="1" CELLBORDER="1" CELLSPACING="0"><tr><td PORT="f100"><font POINT-SIZE="18"><b>struct %v</b></font></td></tr>`, n.name))
-		for _, field := range n.fields {
-			ns := strings.Split(field.fieldName, "#")
-			fields = append(fields, fmt.Sprintf(`<tr><td PORT="%v">%v %v</td></tr>`, ns[0], ns[1], field.fieldType))
-		}
-		gs = append(gs, fmt.Sprintf(nodeTpl, n.name, strings.Join(fields, "")+"</table>"))
-		var edges []string
-		for _, e := range n.edges {
-			edges = append(edges, fmt.Sprintf(`%v->"%v":f100;`, e.from, e.to))
-		}
-		gs = append(gs, strings.Join(edges, "\n"))
-
-	}
-	return fmt.Sprintf(gTpl, strings.Join(gs, "\n"))
+// Graph 以 Graphviz 格式输出依赖图，opts 为过滤及显示选项
+func (gdi *GDIPool) Graph(opts ...GraphOptions) string {
+	s, _ := gdi.ExportGraph(DotExporter{}, opts...)
+	return s
 }
@@ -0,0 +1,102 @@
+package gdi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GraphDiffResult 两个依赖图之间增加及删除的结构体和注入关系
+type GraphDiffResult struct {
+	AddedNodes   []string    `json:"addedNodes"`
+	RemovedNodes []string    `json:"removedNodes"`
+	AddedEdges   []GraphEdge `json:"addedEdges"`
+	RemovedEdges []GraphEdge `json:"removedEdges"`
+}
+
+// Empty 依赖关系是否没有变化
+func (d *GraphDiffResult) Empty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0
+}
+
+// String 每行一个变化，+ 表示增加，- 表示删除
+func (d *GraphDiffResult) String() string {
+	var lines []string
+	for _, n := range d.AddedNodes {
+		lines = append(lines, "+ "+n)
+	}
+	for _, n := range d.RemovedNodes {
+		lines = append(lines, "- "+n)
+	}
+	for _, e := range d.AddedEdges {
+		lines = append(lines, fmt.Sprintf("+ %v.%v -> %v", e.From, e.Field, e.To))
+	}
+	for _, e := range d.RemovedEdges {
+		lines = append(lines, fmt.Sprintf("- %v.%v -> %v", e.From, e.Field, e.To))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GraphDiff 比较两个依赖图，old 可以是保存的 JSON 格式依赖图(json.Unmarshal 到 DependencyGraph)
+func GraphDiff(old, current *DependencyGraph) *GraphDiffResult {
+	return globalGDI.GraphDiff(old, current)
+}
+
+// GraphDiff 比较两个依赖图，注入关系按结构体、属性名称及注入的类型比较，不比较属性的序号
+// Example：diff := pool.GraphDiff(old, current); if !diff.Empty() { t.Errorf("dependencies changed:\n%v", diff) }
+func (gdi *GDIPool) GraphDiff(old, current *DependencyGraph) *GraphDiffResult {
+	if old == nil {
+		old = &DependencyGraph{}
+	}
+	if current == nil {
+		current = &DependencyGraph{}
+	}
+	d := &GraphDiffResult{AddedNodes: []string{}, RemovedNodes: []string{}, AddedEdges: []GraphEdge{}, RemovedEdges: []GraphEdge{}}
+	nodes := func(g *DependencyGraph) map[string]bool {
+		m := make(map[string]bool)
+		for _, n := range g.Nodes {
+			m[n.Name] = true
+		}
+		return m
+	}
+	edgeKey := func(e GraphEdge) string {
+		return e.From + "." + e.Field + "->" + e.To
+	}
+	edges := func(g *DependencyGraph) map[string]bool {
+		m := make(map[string]bool)
+		for _, e := range g.Edges {
+			m[edgeKey(e)] = true
+		}
+		return m
+	}
+	oldNodes, newNodes := nodes(old), nodes(current)
+	oldEdges, newEdges := edges(old), edges(current)
+	for _, n := range current.Nodes {
+		if !oldNodes[n.Name] {
+			d.AddedNodes = append(d.AddedNodes, n.Name)
+		}
+	}
+	for _, n := range old.Nodes {
+		if !newNodes[n.Name] {
+			d.RemovedNodes = append(d.RemovedNodes, n.Name)
+		}
+	}
+	for _, e := range current.Edges {
+		if !oldEdges[edgeKey(e)] {
+			d.AddedEdges = append(d.AddedEdges, e)
+		}
+	}
+	for _, e := range old.Edges {
+		if !newEdges[edgeKey(e)] {
+			d.RemovedEdges = append(d.RemovedEdges, e)
+		}
+	}
+	sort.Strings(d.AddedNodes)
+	sort.Strings(d.RemovedNodes)
+	for _, edges := range [][]GraphEdge{d.AddedEdges, d.RemovedEdges} {
+		sort.Slice(edges, func(i, j int) bool {
+			return edgeKey(edges[i]) < edgeKey(edges[j])
+		})
+	}
+	return d
+}
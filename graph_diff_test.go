@@ -0,0 +1,64 @@
+package gdi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type gdCache struct{}
+type gdRepo struct{}
+type gdService struct {
+	Repo  *gdRepo
+	Cache *gdCache
+}
+type gdServiceV1 struct{ Repo *gdRepo }
+
+func TestGraphDeterministic(t *testing.T) {
+	var first string
+	for i := 0; i < 5; i++ {
+		gp := NewGDIPool()
+		gp.Debug(false)
+		gp.Register(&gdService{}, &gdRepo{}, &gdCache{}, &gdServiceV1{})
+		if err := gp.InitE(); err != nil {
+			t.Fatal(err)
+		}
+		dot := gp.Graph(GraphOptions{ClusterByPackage: true})
+		mmd, _ := gp.ExportGraph(MermaidExporter{})
+		if i == 0 {
+			first = dot + mmd
+		} else if dot+mmd != first {
+			t.Fatalf("graph output should be stable:\n%v\n%v", first, dot+mmd)
+		}
+	}
+}
+
+func TestGraphDiff(t *testing.T) {
+	old := &DependencyGraph{
+		Nodes: []GraphNode{{Name: "*svc.Service"}, {Name: "*repo.Repo"}, {Name: "*legacy.Client"}},
+		Edges: []GraphEdge{
+			{From: "*svc.Service", Field: "Repo", Index: 0, To: "*repo.Repo"},
+			{From: "*svc.Service", Field: "Client", Index: 1, To: "*legacy.Client"},
+		},
+	}
+	data, _ := json.Marshal(old)
+	var loaded DependencyGraph
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	current := &DependencyGraph{
+		Nodes: []GraphNode{{Name: "*svc.Service"}, {Name: "*repo.Repo"}, {Name: "*infra.DB"}},
+		Edges: []GraphEdge{
+			{From: "*svc.Service", Field: "Repo", Index: 3, To: "*repo.Repo"},
+			{From: "*repo.Repo", Field: "DB", Index: 0, To: "*infra.DB"},
+		},
+	}
+	gp := NewGDIPool()
+	if d := gp.GraphDiff(current, current); !d.Empty() {
+		t.Fatalf("same graph should have no diff %v", d)
+	}
+	d := gp.GraphDiff(&loaded, current)
+	expect := "+ *infra.DB\n- *legacy.Client\n+ *repo.Repo.DB -> *infra.DB\n- *svc.Service.Client -> *legacy.Client"
+	if d.Empty() || d.String() != expect {
+		t.Fatalf("unexpected diff:\n%v", d)
+	}
+}
@@ -0,0 +1,330 @@
+package gdi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DependencyGraph 依赖图，Nodes 为注入时访问过的结构体，Edges 为属性的注入关系
+type DependencyGraph struct {
+	Nodes   []GraphNode  `json:"nodes"`
+	Edges   []GraphEdge  `json:"edges"`
+	Options GraphOptions `json:"-"` // 生成依赖图时的选项，输出格式可以根据其中的显示选项输出
+}
+
+// GraphNode 依赖图中的结构体，Kind 为来源(NodeRegistered、NodeReadOnly、NodeAutoCreated、NodeNamed)，没有保存在容器中时为空
+type GraphNode struct {
+	Name    string       `json:"name"`
+	PkgPath string       `json:"pkgPath"`
+	Kind    string       `json:"kind,omitempty"`
+	Fields  []GraphField `json:"fields"`
+}
+
+// GraphField 结构体的属性，Index 为属性的序号
+type GraphField struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+}
+
+// GraphEdge From 的属性 Field 注入了 To 类型的对象，构造函数的参数 Field 为 arg#N，Index 为 -1
+type GraphEdge struct {
+	From  string `json:"from"`
+	Field string `json:"field"`
+	Index int    `json:"index"`
+	To    string `json:"to"`
+}
+
+// sort 结构体按名称、属性按序号、注入关系按结构体名称、属性序号及类型排序，保证每次输出的内容相同
+func (g *DependencyGraph) sort() {
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Name < g.Nodes[j].Name
+	})
+	for _, n := range g.Nodes {
+		sort.SliceStable(n.Fields, func(i, j int) bool {
+			return n.Fields[i].Index < n.Fields[j].Index
+		})
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.Index != b.Index {
+			return a.Index < b.Index
+		}
+		return a.To < b.To
+	})
+}
+
+// GraphExporter 依赖图的输出格式
+type GraphExporter interface {
+	Export(g *DependencyGraph) (string, error)
+}
+
+// DotExporter Graphviz 格式
+type DotExporter struct{}
+
+// MermaidExporter Mermaid 流程图格式，可以直接在 GitHub/GitLab 的 Markdown 中显示
+type MermaidExporter struct{}
+
+// PlantUMLExporter PlantUML 类图格式
+type PlantUMLExporter struct{}
+
+// JSONExporter JSON 格式，结构为 DependencyGraph
+type JSONExporter struct{}
+
+// defaultGraphExporters 内置的文件扩展名及输出格式
+func defaultGraphExporters() map[string]GraphExporter {
+	return map[string]GraphExporter{
+		".dot":      DotExporter{},
+		".gv":       DotExporter{},
+		".mmd":      MermaidExporter{},
+		".mermaid":  MermaidExporter{},
+		".puml":     PlantUMLExporter{},
+		".plantuml": PlantUMLExporter{},
+		".json":     JSONExporter{},
+	}
+}
+
+// RegisterGraphExporter 按文件扩展名(如 ".svg")为全局容器注册 SaveGraphToFile 使用的输出格式
+func RegisterGraphExporter(ext string, exporter GraphExporter) {
+	globalGDI.RegisterGraphExporter(ext, exporter)
+}
+
+// RegisterGraphExporter 按文件扩展名(如 ".svg")注册 SaveGraphToFile 使用的输出格式，只对当前容器有效
+func (gdi *GDIPool) RegisterGraphExporter(ext string, exporter GraphExporter) {
+	gdi.graphExportersLocker.Lock()
+	defer gdi.graphExportersLocker.Unlock()
+	gdi.graphExporters[strings.ToLower(ext)] = exporter
+}
+
+// copyGraphExporters 复制容器注册的输出格式，用于子容器及测试容器
+func (gdi *GDIPool) copyGraphExporters() map[string]GraphExporter {
+	gdi.graphExportersLocker.RLock()
+	defer gdi.graphExportersLocker.RUnlock()
+	return copyMap(gdi.graphExporters).(map[string]GraphExporter)
+}
+
+// graphExporterByExt 根据文件扩展名选择输出格式，未注册的扩展名使用 Graphviz 格式
+func (gdi *GDIPool) graphExporterByExt(fpath string) GraphExporter {
+	gdi.graphExportersLocker.RLock()
+	defer gdi.graphExportersLocker.RUnlock()
+	if e, ok := gdi.graphExporters[strings.ToLower(filepath.Ext(fpath))]; ok {
+		return e
+	}
+	return DotExporter{}
+}
+
+// ExportGraph 以指定格式输出全局容器的依赖图
+func ExportGraph(exporter GraphExporter, opts ...GraphOptions) (string, error) {
+	return globalGDI.ExportGraph(exporter, opts...)
+}
+
+// DependencyGraph 获取依赖图，opts 为过滤及显示选项
+func (gdi *GDIPool) DependencyGraph(opts ...GraphOptions) (*DependencyGraph, error) {
+	objs := gdi.containerNodes()
+	dg := &DependencyGraph{Nodes: []GraphNode{}, Edges: gdi.creatorEdges()}
+	gdi.g.lock.Lock()
+	for _, n := range gdi.g.nodes {
+		gn := GraphNode{Name: n.name, PkgPath: n.pkgPath, Kind: objs[n.name].Kind, Fields: []GraphField{}}
+		for _, f := range n.fields {
+			gn.Fields = append(gn.Fields, GraphField{Index: f.index, Name: f.fieldName, Type: f.fieldType})
+		}
+		dg.Nodes = append(dg.Nodes, gn)
+		for _, e := range n.edges {
+			dg.Edges = append(dg.Edges, GraphEdge{From: e.from, Field: e.field, Index: e.index, To: e.to})
+		}
+	}
+	for _, e := range dg.Edges { // 只读对象等没有注入过属性的对象
+		for _, name := range []string{e.From, e.To} {
+			if n, ok := objs[name]; ok && gdi.g.nodes[name] == nil {
+				dg.Nodes = append(dg.Nodes, n)
+				delete(objs, name)
+			}
+		}
+	}
+	gdi.g.lock.Unlock()
+	dg.sort()
+	for _, o := range opts {
+		var err error
+		if dg, err = o.filter(dg); err != nil {
+			return nil, err
+		}
+	}
+	return dg, nil
+}
+
+// creatorEdges 构造函数参数的注入关系
+func (gdi *GDIPool) creatorEdges() []GraphEdge {
+	edges := []GraphEdge{}
+	gdi.lc.lock.Lock()
+	defer gdi.lc.lock.Unlock()
+	for k, deps := range gdi.lc.deps {
+		for _, d := range deps {
+			if !strings.HasPrefix(d.field, creatorArgField) {
+				continue
+			}
+			if dk, ok := lifecycleKey(d.value); ok {
+				edges = append(edges, GraphEdge{From: reflect.TypeOf(k).String(), Field: d.field, Index: -1, To: reflect.TypeOf(dk).String()})
+			}
+		}
+	}
+	return edges
+}
+
+// ExportGraph 以指定格式输出依赖图
+// Example：pool.ExportGraph(gdi.MermaidExporter{}, gdi.GraphOptions{Package: "myapp/service"})
+func (gdi *GDIPool) ExportGraph(exporter GraphExporter, opts ...GraphOptions) (string, error) {
+	dg, err := gdi.DependencyGraph(opts...)
+	if err != nil {
+		return "", err
+	}
+	return exporter.Export(dg)
+}
+
+// SaveGraphToFile 保存依赖图，根据扩展名选择格式：.mmd/.mermaid 为 Mermaid，.puml/.plantuml 为 PlantUML，.json 为 JSON，其它为 Graphviz
+func (gdi *GDIPool) SaveGraphToFile(fpath string, opts ...GraphOptions) error {
+	content, err := gdi.ExportGraph(gdi.graphExporterByExt(fpath), opts...)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fpath, []byte(content), 0777)
+}
+
+// dotColors 按来源显示的颜色
+var dotColors = map[string]string{
+	NodeRegistered:  "#d5e8d4",
+	NodeReadOnly:    "#dae8fc",
+	NodeAutoCreated: "#f8cecc",
+	NodeNamed:       "#fff2cc",
+}
+
+func (DotExporter) Export(g *DependencyGraph) (string, error) {
+	var gs []string
+	/*
+		"node0" [
+		label = "<f0> 0x10ba8| <f1>"
+		shape = "record"
+		];
+	*/
+	nodeTpl := `
+   "%v" [
+     label = <%v>
+     shape = "none"
+ ]
+`
+	gTpl := `
+digraph { 
+
+rankdir=LR;
+  %v
+}
+`
+	edges := make(map[string][]string)
+	for _, e := range g.Edges {
+		port := e.Index
+		if port < 0 { // 构造函数参数从结构体名称连出
+			port = 100
+		}
+		edges[e.From] = append(edges[e.From], fmt.Sprintf(`"%v":f%v->"%v":f100;`, e.From, port, e.To))
+	}
+	var pkgs []string
+	clusters := make(map[string][]string)
+	for _, n := range g.Nodes {
+		table := `<table BORDER="1" CELLBORDER="1" CELLSPACING="0">`
+		if color, ok := dotColors[n.Kind]; ok && g.Options.ColorByProvenance {
+			table = fmt.Sprintf(`<table BORDER="1" CELLBORDER="1" CELLSPACING="0" BGCOLOR="%v">`, color)
+		}
+		var fields []string
+		fields = append(fields, fmt.Sprintf(`%v<tr><td PORT="f100"><font POINT-SIZE="18"><b>struct %v</b></font></td></tr>`, table, n.Name))
+		for _, field := range n.Fields {
+			fields = append(fields, fmt.Sprintf(`<tr><td PORT="f%v">%v %v</td></tr>`, field.Index, field.Name, field.Type))
+		}
+		nodeStr := fmt.Sprintf(nodeTpl, n.Name, strings.Join(fields, "")+"</table>")
+		if g.Options.ClusterByPackage {
+			if _, ok := clusters[n.PkgPath]; !ok {
+				pkgs = append(pkgs, n.PkgPath)
+			}
+			clusters[n.PkgPath] = append(clusters[n.PkgPath], nodeStr)
+		} else {
+			gs = append(gs, nodeStr)
+		}
+		gs = append(gs, strings.Join(edges[n.Name], "\n"))
+	}
+	sort.Strings(pkgs)
+	for i, pkg := range pkgs {
+		gs = append(gs, fmt.Sprintf("  subgraph \"cluster_%v\" {\n  label = \"%v\"\n%v\n  }", i, pkg, strings.Join(clusters[pkg], "\n")))
+	}
+	return fmt.Sprintf(gTpl, strings.Join(gs, "\n")), nil
+}
+
+// graphIDs 为结构体及被注入的类型分配 n0、n1... 形式的标识
+func graphIDs(g *DependencyGraph) (map[string]string, []string) {
+	ids := make(map[string]string)
+	var names []string
+	add := func(name string) {
+		if _, ok := ids[name]; !ok {
+			ids[name] = fmt.Sprintf("n%v", len(names))
+			names = append(names, name)
+		}
+	}
+	for _, n := range g.Nodes {
+		add(n.Name)
+	}
+	for _, e := range g.Edges {
+		add(e.From)
+		add(e.To)
+	}
+	return ids, names
+}
+
+func (MermaidExporter) Export(g *DependencyGraph) (string, error) {
+	ids, names := graphIDs(g)
+	lines := []string{"flowchart LR"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf(`  %v["%v"]`, ids[name], strings.ReplaceAll(name, `"`, "#quot;")))
+	}
+	for _, e := range g.Edges {
+		lines = append(lines, fmt.Sprintf(`  %v -->|%v| %v`, ids[e.From], e.Field, ids[e.To]))
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func (PlantUMLExporter) Export(g *DependencyGraph) (string, error) {
+	ids, names := graphIDs(g)
+	fields := make(map[string][]GraphField)
+	for _, n := range g.Nodes {
+		fields[n.Name] = n.Fields
+	}
+	lines := []string{"@startuml", "left to right direction"}
+	for _, name := range names {
+		if len(fields[name]) == 0 {
+			lines = append(lines, fmt.Sprintf(`class "%v" as %v`, name, ids[name]))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(`class "%v" as %v {`, name, ids[name]))
+		for _, f := range fields[name] {
+			lines = append(lines, fmt.Sprintf("  %v %v", f.Name, f.Type))
+		}
+		lines = append(lines, "}")
+	}
+	for _, e := range g.Edges {
+		lines = append(lines, fmt.Sprintf("%v --> %v : %v", ids[e.From], ids[e.To], e.Field))
+	}
+	lines = append(lines, "@enduml")
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func (JSONExporter) Export(g *DependencyGraph) (string, error) {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
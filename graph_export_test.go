@@ -0,0 +1,146 @@
+package gdi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type geRepo struct{}
+
+type geService struct {
+	Repo *geRepo
+	Name string
+}
+
+func TestExportGraph(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&geRepo{}, &geService{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	dg, err := gp.DependencyGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, e := range dg.Edges {
+		if e == (GraphEdge{From: "*gdi.geService", Field: "Repo", Index: 0, To: "*gdi.geRepo"}) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("edge not found in %+v", dg.Edges)
+	}
+	if dot := gp.Graph(); !strings.Contains(dot, `"*gdi.geService":f0->"*gdi.geRepo":f100;`) || !strings.Contains(dot, `<td PORT="f1">Name string</td>`) {
+		t.Fatalf("unexpected dot %v", dot)
+	}
+	mmd, err := gp.ExportGraph(MermaidExporter{})
+	if err != nil || !strings.HasPrefix(mmd, "flowchart LR") || !strings.Contains(mmd, `-->|Repo|`) || !strings.Contains(mmd, `["*gdi.geService"]`) {
+		t.Fatalf("unexpected mermaid %v %v", mmd, err)
+	}
+	puml, err := gp.ExportGraph(PlantUMLExporter{})
+	if err != nil || !strings.HasPrefix(puml, "@startuml") || !strings.Contains(puml, "  Name string") || !strings.Contains(puml, " : Repo") {
+		t.Fatalf("unexpected plantuml %v %v", puml, err)
+	}
+
+	dir := t.TempDir()
+	expects := map[string]string{"deps.mmd": "flowchart LR", "deps.puml": "@startuml", "deps.json": "{", "deps.dot": "\ndigraph"}
+	for name, prefix := range expects {
+		fpath := filepath.Join(dir, name)
+		if err := gp.SaveGraphToFile(fpath); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadFile(fpath)
+		if !strings.HasPrefix(string(data), prefix) {
+			t.Fatalf("%v: unexpected content %v", name, string(data))
+		}
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(dir, "deps.json"))
+	var decoded DependencyGraph
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Nodes) != len(dg.Nodes) || len(decoded.Edges) != len(dg.Edges) {
+		t.Fatalf("unexpected json %v %v", string(data), err)
+	}
+}
+
+type geHandler interface{ Handle() }
+type geHandlerA struct{}
+type geHandlerB struct{}
+type geRouter struct {
+	Handlers []geHandler `inject:"all"`
+}
+
+func (h *geHandlerA) Handle() {}
+func (h *geHandlerB) Handle() {}
+
+func TestExportGraphInjectAll(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&geHandlerA{}, &geHandlerB{}, &geRouter{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	dg := mustGraph(t, gp)
+	var edges []GraphEdge
+	for _, e := range dg.Edges {
+		if e.From == "*gdi.geRouter" {
+			edges = append(edges, e)
+		}
+	}
+	expects := []GraphEdge{
+		{From: "*gdi.geRouter", Field: "Handlers", Index: 0, To: "*gdi.geHandlerA"},
+		{From: "*gdi.geRouter", Field: "Handlers", Index: 0, To: "*gdi.geHandlerB"},
+	}
+	if len(edges) != 2 || edges[0] != expects[0] || edges[1] != expects[1] {
+		t.Fatalf("unexpected edges %+v", edges)
+	}
+	mmd, _ := gp.ExportGraph(MermaidExporter{})
+	puml, _ := gp.ExportGraph(PlantUMLExporter{})
+	js, _ := gp.ExportGraph(JSONExporter{})
+	for _, out := range []string{mmd, puml, js} {
+		if strings.Contains(out, `:f0`) {
+			t.Fatalf("edge source should be the struct type:\n%v", out)
+		}
+	}
+	if !strings.Contains(mmd, "-->|Handlers|") || !strings.Contains(puml, " : Handlers") {
+		t.Fatalf("unexpected output:\n%v\n%v", mmd, puml)
+	}
+	if !strings.Contains(gp.Graph(), `"*gdi.geRouter":f0->"*gdi.geHandlerA":f100;`) {
+		t.Fatalf("unexpected dot %v", gp.Graph())
+	}
+}
+
+type geTextExporter struct{}
+
+func (geTextExporter) Export(g *DependencyGraph) (string, error) {
+	return "text", nil
+}
+
+func TestRegisterGraphExporter(t *testing.T) {
+	gp := NewGDIPool()
+	other := NewGDIPool()
+	gp.Debug(false)
+	other.Debug(false)
+	gp.RegisterGraphExporter(".TXT", geTextExporter{})
+	dir := t.TempDir()
+	for _, p := range []*GDIPool{gp, other} {
+		if err := p.InitE(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := gp.SaveGraphToFile(filepath.Join(dir, "gp.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.SaveGraphToFile(filepath.Join(dir, "other.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "gp.txt")); string(data) != "text" {
+		t.Fatalf("registered exporter should be used, got %v", string(data))
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "other.txt")); !strings.HasPrefix(string(data), "\ndigraph") {
+		t.Fatalf("exporter should not be shared between pools, got %v", string(data))
+	}
+}
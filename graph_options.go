@@ -0,0 +1,141 @@
+package gdi
+
+import (
+	"reflect"
+	"regexp"
+)
+
+// 依赖图中结构体的来源
+const (
+	NodeRegistered  = "registered"  // 按类型注册
+	NodeReadOnly    = "readOnly"    // 按类型注册的只读对象
+	NodeAutoCreated = "autoCreated" // 注入时自动创建
+	NodeNamed       = "named"       // 按名称注册
+)
+
+// GraphOptions 依赖图的过滤及显示选项
+// Example：pool.Graph(gdi.GraphOptions{Root: (*Service)(nil), Hops: 2, InjectedOnly: true, ClusterByPackage: true})
+type GraphOptions struct {
+	Package           string      // 只保留 PkgPath 匹配该正则的结构体
+	Root              interface{} // 只保留与 Root 相距 Hops 之内的结构体，可以是对象、类型 (*T)(nil) 或类型名称
+	Hops              int         // 0 表示不限制距离
+	InjectedOnly      bool        // 只显示注入过的属性
+	ClusterByPackage  bool        // 按 PkgPath 分组显示(Graphviz subgraph)
+	ColorByProvenance bool        // 按来源显示不同的颜色
+}
+
+// containerNodes 容器中保存的对象对应的结构体，Kind 为来源，同一类型有多个来源时按自动创建、名称、只读、类型的顺序选择
+func (gdi *GDIPool) containerNodes() map[string]GraphNode {
+	nodes := make(map[string]GraphNode)
+	priority := map[string]int{NodeRegistered: 1, NodeReadOnly: 2, NodeNamed: 3, NodeAutoCreated: 4}
+	gdi.ttvLocker.RLock()
+	defer gdi.ttvLocker.RUnlock()
+	add := func(v reflect.Value, kind string) {
+		if k, ok := lifecycleKey(v); ok && gdi.provenance[k] == ProvenanceAutoCreate {
+			kind = NodeAutoCreated
+		}
+		t := v.Type()
+		if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			return
+		}
+		if priority[kind] > priority[nodes[t.String()].Kind] {
+			nodes[t.String()] = GraphNode{Name: t.String(), PkgPath: t.Elem().PkgPath(), Kind: kind, Fields: []GraphField{}}
+		}
+	}
+	for _, v := range gdi.typeToValues {
+		add(v, NodeRegistered)
+	}
+	for t, v := range gdi.typeToValuesReadOnly {
+		if t != contextType {
+			add(v, NodeReadOnly)
+		}
+	}
+	for _, v := range gdi.namesToValues {
+		add(v, NodeNamed)
+	}
+	for _, v := range gdi.namesToValuesReadOnly {
+		add(v, NodeNamed)
+	}
+	return nodes
+}
+
+// filter 按选项过滤依赖图，过滤掉的结构体相关的注入关系也一并去掉
+func (o GraphOptions) filter(dg *DependencyGraph) (*DependencyGraph, error) {
+	keep := make(map[string]bool)
+	for _, e := range dg.Edges {
+		keep[e.From], keep[e.To] = true, true
+	}
+	for _, n := range dg.Nodes {
+		keep[n.Name] = true
+	}
+	if o.Package != "" {
+		re, err := regexp.Compile(o.Package)
+		if err != nil {
+			return nil, err
+		}
+		for name := range keep {
+			keep[name] = false // 不是结构体的类型(如延迟注入的接口)无法判断所在的包
+		}
+		for _, n := range dg.Nodes {
+			keep[n.Name] = re.MatchString(n.PkgPath)
+		}
+	}
+	if o.Root != nil {
+		root, ok := o.Root.(string)
+		if !ok {
+			root = reflect.TypeOf(o.Root).String()
+		}
+		neighbors := make(map[string][]string)
+		for _, e := range dg.Edges {
+			neighbors[e.From] = append(neighbors[e.From], e.To)
+			neighbors[e.To] = append(neighbors[e.To], e.From)
+		}
+		distance := map[string]int{root: 0}
+		queue := []string{root}
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			if o.Hops > 0 && distance[name] >= o.Hops {
+				continue
+			}
+			for _, next := range neighbors[name] {
+				if _, ok := distance[next]; !ok && keep[next] {
+					distance[next] = distance[name] + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+		for name := range keep {
+			if _, ok := distance[name]; !ok {
+				keep[name] = false
+			}
+		}
+	}
+	result := &DependencyGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}, Options: o}
+	injected := make(map[string]map[int]bool)
+	for _, e := range dg.Edges {
+		if keep[e.From] && keep[e.To] {
+			result.Edges = append(result.Edges, e)
+			if injected[e.From] == nil {
+				injected[e.From] = make(map[int]bool)
+			}
+			injected[e.From][e.Index] = true
+		}
+	}
+	for _, n := range dg.Nodes {
+		if !keep[n.Name] {
+			continue
+		}
+		if o.InjectedOnly {
+			fields := []GraphField{}
+			for _, f := range n.Fields {
+				if injected[n.Name][f.Index] {
+					fields = append(fields, f)
+				}
+			}
+			n.Fields = fields
+		}
+		result.Nodes = append(result.Nodes, n)
+	}
+	return result, nil
+}
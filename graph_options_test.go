@@ -0,0 +1,80 @@
+package gdi
+
+import (
+	"strings"
+	"testing"
+)
+
+type goA struct {
+	B      *goB
+	Config *goConfig
+	Name   string
+}
+type goB struct{ C *goC }
+type goC struct{ D *goD }
+type goD struct{}
+type goConfig struct{}
+type goNamed struct{}
+
+func TestGraphOptions(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&goA{}, &goB{}, func() (*goNamed, string) { return &goNamed{}, "named" })
+	gp.RegisterReadOnly(&goConfig{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	names := func(dg *DependencyGraph) map[string]string {
+		m := make(map[string]string)
+		for _, n := range dg.Nodes {
+			m[n.Name] = n.Kind
+		}
+		return m
+	}
+	dg, err := gp.DependencyGraph(GraphOptions{Root: (*goA)(nil), Hops: 2, InjectedOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := names(dg)
+	if len(nodes) != 4 || nodes["*gdi.goA"] != NodeRegistered || nodes["*gdi.goC"] != NodeAutoCreated {
+		t.Fatalf("unexpected nodes %v", nodes)
+	}
+	if len(dg.Edges) != 3 {
+		t.Fatalf("unexpected edges %+v", dg.Edges)
+	}
+	for _, n := range dg.Nodes {
+		if n.Name == "*gdi.goA" && (len(n.Fields) != 2 || n.Fields[1].Name != "Config") {
+			t.Fatalf("non-injected fields should be hidden %+v", n.Fields)
+		}
+	}
+	if dg, _ = gp.DependencyGraph(GraphOptions{Root: "*gdi.goD"}); len(dg.Nodes) != 5 {
+		t.Fatalf("unlimited hops should keep the connected types %v", names(dg))
+	}
+	if dg, _ = gp.DependencyGraph(GraphOptions{Package: "^other/"}); len(dg.Nodes) != 0 || len(dg.Edges) != 0 {
+		t.Fatalf("package filter should drop all types %v", names(dg))
+	}
+	if _, err := gp.DependencyGraph(GraphOptions{Package: "("}); err == nil {
+		t.Fatal("invalid package regexp should fail")
+	}
+	nodes = names(mustGraph(t, gp))
+	if nodes["*gdi.goConfig"] != NodeReadOnly || nodes["*gdi.goNamed"] != NodeNamed {
+		t.Fatalf("unexpected kinds %v", nodes)
+	}
+
+	dot := gp.Graph(GraphOptions{ClusterByPackage: true, ColorByProvenance: true})
+	if !strings.Contains(dot, `subgraph "cluster_0"`) || !strings.Contains(dot, `label = "github.com/sjqzhang/gdi"`) ||
+		!strings.Contains(dot, `BGCOLOR="`+dotColors[NodeAutoCreated]+`"><tr><td PORT="f100"><font POINT-SIZE="18"><b>struct *gdi.goC`) {
+		t.Fatalf("unexpected dot %v", dot)
+	}
+	if strings.Contains(gp.Graph(), "BGCOLOR") || strings.Contains(gp.Graph(), "subgraph") {
+		t.Fatal("color and cluster should be disabled by default")
+	}
+}
+
+func mustGraph(t *testing.T, gp *GDIPool) *DependencyGraph {
+	dg, err := gp.DependencyGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dg
+}
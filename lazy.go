@@ -0,0 +1,186 @@
+package gdi
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Lazy 延迟注入，第一次调用 Get 时才从容器中获取(或自动创建)对象并缓存，可用于打破构造函数的循环依赖
+// Example：type Service struct { Mailer gdi.Lazy[*Mailer] }，或构造函数参数 func(m *gdi.Lazy[*Mailer]) *Service，
+// 函数类型的属性需要标记 inject:"lazy"：type Service struct { Mailer func() *Mailer `inject:"lazy"` }
+type Lazy[T any] struct {
+	once    sync.Once
+	resolve func() (reflect.Value, error)
+	value   T
+	err     error
+}
+
+// Get 获取对象，第一次调用时才从容器中获取，获取失败时panic
+func (l *Lazy[T]) Get() T {
+	value, err := l.GetE()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// GetE 获取对象，第一次调用时才从容器中获取
+func (l *Lazy[T]) GetE() (T, error) {
+	l.once.Do(func() {
+		if l.resolve == nil {
+			l.err = fmt.Errorf("(ERROR) %v is not injected", reflect.TypeOf(l).Elem())
+			return
+		}
+		value, err := l.resolve()
+		if err != nil {
+			l.err = err
+			return
+		}
+		l.value = value.Interface().(T)
+	})
+	return l.value, l.err
+}
+
+func (l *Lazy[T]) lazyTarget() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (l *Lazy[T]) setResolver(resolve func() (reflect.Value, error)) {
+	l.resolve = resolve
+}
+
+func (l *Lazy[T]) injected() bool {
+	return l.resolve != nil
+}
+
+// lazyValue 由 *Lazy[T] 实现，用于通过反射识别延迟注入的属性
+type lazyValue interface {
+	lazyTarget() reflect.Type
+	setResolver(resolve func() (reflect.Value, error))
+	injected() bool
+}
+
+var lazyValueType = reflect.TypeOf((*lazyValue)(nil)).Elem()
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// lazyTargetOf 判断是否为延迟注入的类型：Lazy[T]、*Lazy[T]、func() T 或 func() (T, error)，T 为指针或接口(error 除外)，返回 T
+func lazyTargetOf(t reflect.Type) (reflect.Type, bool) {
+	switch t.Kind() {
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(lazyValueType) {
+			return reflect.New(t).Interface().(lazyValue).lazyTarget(), true
+		}
+	case reflect.Ptr:
+		if t.Elem().Kind() == reflect.Struct && t.Implements(lazyValueType) {
+			return reflect.New(t.Elem()).Interface().(lazyValue).lazyTarget(), true
+		}
+	case reflect.Func:
+		if t.NumIn() != 0 || t.IsVariadic() || t.NumOut() == 0 || t.NumOut() > 2 || t.NumOut() == 2 && t.Out(1) != errorType {
+			return nil, false
+		}
+		if out := t.Out(0); out.Kind() == reflect.Ptr && out.Elem().Kind() == reflect.Struct || out.Kind() == reflect.Interface && out != errorType {
+			return out, true
+		}
+	}
+	return nil, false
+}
+
+// lazyFieldTarget 属性是否为延迟注入：Lazy[T]、*Lazy[T]，或标记了 inject:"lazy" 的 func() T、func() (T, error)，
+// 没有标记的函数类型属性(如回调函数)不会被注入
+func (gdi *GDIPool) lazyFieldTarget(f reflect.StructField) (reflect.Type, bool) {
+	target, ok := lazyTargetOf(f.Type)
+	if !ok {
+		return nil, false
+	}
+	if f.Type.Kind() == reflect.Func {
+		if _, tagged := gdi.getTagAttr(f, "lazy"); !tagged {
+			return nil, false
+		}
+	}
+	return target, true
+}
+
+// lazyInjected 延迟注入的属性是否已经注入
+func lazyInjected(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.Struct:
+		return field.Addr().Interface().(lazyValue).injected()
+	default:
+		return !field.IsNil()
+	}
+}
+
+// newLazy 创建类型为 t 的延迟注入值，第一次调用时通过 resolve 获取对象并缓存
+func newLazy(t reflect.Type, resolve func() (reflect.Value, error)) reflect.Value {
+	switch t.Kind() {
+	case reflect.Struct:
+		l := reflect.New(t)
+		l.Interface().(lazyValue).setResolver(resolve)
+		return l.Elem()
+	case reflect.Ptr:
+		l := reflect.New(t.Elem())
+		l.Interface().(lazyValue).setResolver(resolve)
+		return l
+	}
+	var once sync.Once
+	var value reflect.Value
+	var err error
+	return reflect.MakeFunc(t, func([]reflect.Value) []reflect.Value {
+		once.Do(func() {
+			value, err = resolve()
+		})
+		if t.NumOut() == 1 {
+			if err != nil {
+				panic(err)
+			}
+			return []reflect.Value{value}
+		}
+		if err != nil {
+			return []reflect.Value{reflect.Zero(t.Out(0)), reflect.ValueOf(&err).Elem()}
+		}
+		return []reflect.Value{value, reflect.Zero(errorType)}
+	})
+}
+
+// lazyResolver 返回按属性注入规则获取 t 的函数，owner 为属性所在的对象或构造函数，f 为属性(构造函数参数时为 nil)
+func (gdi *GDIPool) lazyResolver(t reflect.Type, owner reflect.Value, fieldName string, f *reflect.StructField) func() (reflect.Value, error) {
+	return func() (reflect.Value, error) {
+		var value reflect.Value
+		if t.Kind() == reflect.Interface {
+			handled := false
+			var err error
+			if f != nil {
+				value, handled, err = gdi.getQualified(t, *f, false, false, gdi.autoCreate)
+			}
+			if !handled {
+				value, err = gdi.getByInterface(t, fieldName, owner, false, false, gdi.autoCreate)
+			}
+			if err != nil {
+				return reflect.Value{}, err
+			}
+		} else if v, ok := gdi.resolve(t); ok {
+			value = v
+		} else if gdi.autoCreate {
+			value = reflect.New(t.Elem())
+			gdi.warn(fmt.Sprintf("\u001B[1;35mautoCreate\u001B[0m type:%v fieldName:%v of %v", t, fieldName, owner.Type()), "event", eventAutoCreate, "type", t.String(), "field", fieldName, "owner", owner.Type().String())
+			gdi.emitAutoCreate(AutoCreateEvent{Type: t, Owner: owner.Type(), Field: fieldName})
+			gdi.setAutoCreated(t, value)
+			gdi.build(value, false, false)
+		} else {
+			return reflect.Value{}, fmt.Errorf("type:%v fieldName:%v of %v not found", t, fieldName, owner.Type())
+		}
+		result := reflect.New(t).Elem()
+		result.Set(value)
+		return result, nil
+	}
+}
+
+// lazyArg 构造函数的参数为延迟注入类型时，返回延迟获取的参数
+func (gdi *GDIPool) lazyArg(in reflect.Type, creator interface{}) (reflect.Value, bool) {
+	target, ok := lazyTargetOf(in)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	return newLazy(in, gdi.lazyResolver(target, reflect.ValueOf(creator), in.String(), nil)), true
+}
@@ -0,0 +1,122 @@
+package gdi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type lzMailer struct{ Host string }
+
+type lzSender interface {
+	Send() string
+}
+
+type lzSMTP struct{}
+
+func (s *lzSMTP) Send() string { return "smtp" }
+
+type lzService struct {
+	Mailer  Lazy[*lzMailer]
+	Ptr     *Lazy[*lzMailer]
+	Func    func() *lzMailer          `inject:"lazy"`
+	FuncErr func() (*lzMailer, error) `inject:"lazy"`
+	Sender  func() lzSender           `inject:"lazy"`
+	Auto    Lazy[*lzAuto]
+}
+
+type lzAuto struct{}
+
+var lzCreated int
+
+type lzA struct{ B *lzB }
+type lzB struct{ A *Lazy[*lzA] }
+
+func TestLazy(t *testing.T) {
+	lzCreated = 0
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func() (*lzMailer, error) {
+		lzCreated++
+		return &lzMailer{Host: "smtp.example.com"}, nil
+	}, &lzSMTP{}, &lzService{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	svc := gp.Get(&lzService{}).(*lzService)
+	m := svc.Mailer.Get()
+	if m == nil || m.Host != "smtp.example.com" || svc.Ptr.Get() != m || svc.Func() != m {
+		t.Fatal("lazy fields should resolve the registered object")
+	}
+	if v, err := svc.FuncErr(); err != nil || v != m {
+		t.Fatalf("unexpected %v %v", v, err)
+	}
+	if svc.Sender().Send() != "smtp" {
+		t.Fatal("lazy interface should resolve the implementation")
+	}
+	if lzCreated != 1 {
+		t.Fatalf("object should be created once, got %v", lzCreated)
+	}
+	if _, ok := gp.GetWithCheck(&lzAuto{}); ok {
+		t.Fatal("lazy field should not be created before first call")
+	}
+	if svc.Auto.Get() == nil {
+		t.Fatal("lazy field should be auto created on first call")
+	}
+	if _, ok := gp.GetWithCheck(&lzAuto{}); !ok {
+		t.Fatal("auto created object should be registered")
+	}
+
+	var l Lazy[*lzMailer]
+	if _, err := l.GetE(); err == nil || !strings.Contains(err.Error(), "not injected") {
+		t.Fatalf("expect not injected error, got %v", err)
+	}
+}
+
+func TestLazyBreakCycle(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func(b *lzB) *lzA {
+		return &lzA{B: b}
+	}, func(a *Lazy[*lzA]) *lzB {
+		return &lzB{A: a}
+	})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	a := gp.Get(&lzA{}).(*lzA)
+	if a.B == nil || a.B.A.Get() != a {
+		t.Fatal("lazy parameter should break constructor cycle")
+	}
+}
+
+type lzCallbacks struct {
+	OnDone  func() error
+	Factory func() *lzMailer
+	onStop  func() error
+	private Lazy[*lzMailer]
+	Mailer  Lazy[*lzMailer]
+}
+
+func TestLazyIgnoresCallbacks(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.IgnorePrivate(true)
+	gp.Register(&lzMailer{}, &lzCallbacks{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	c := gp.Get(&lzCallbacks{}).(*lzCallbacks)
+	if c.OnDone != nil || c.Factory != nil || c.onStop != nil {
+		t.Fatal("func fields without inject:\"lazy\" should be left nil")
+	}
+	if c.private.injected() {
+		t.Fatal("private lazy field should be ignored when IgnorePrivate is set")
+	}
+	if c.Mailer.Get() == nil {
+		t.Fatal("exported lazy field should be injected")
+	}
+	if _, ok := lazyTargetOf(reflect.TypeOf(func() error { return nil })); ok {
+		t.Fatal("func() error should not be a lazy target")
+	}
+}
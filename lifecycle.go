@@ -0,0 +1,180 @@
+package gdi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Initializer 对象完成属性注入后，容器会按依赖顺序调用 AfterInject
+type Initializer interface {
+	AfterInject() error
+}
+
+// Closer 调用 Shutdown 时，容器会按依赖逆序调用 Close
+type Closer interface {
+	Close() error
+}
+
+// dependency 对象通过属性 field 依赖 value
+type dependency struct {
+	field string
+	value reflect.Value
+}
+
+type lifecycle struct {
+	lock    sync.Mutex
+	deps    map[interface{}][]dependency // 对象 -> 被注入的依赖
+	started map[interface{}]bool         // 已调用 AfterInject
+	closed  map[interface{}]bool         // 已调用 Close
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		deps:    make(map[interface{}][]dependency),
+		started: make(map[interface{}]bool),
+		closed:  make(map[interface{}]bool),
+	}
+}
+
+// Shutdown 关闭容器，按依赖逆序调用对象的 Close 方法
+func Shutdown(ctx context.Context) error {
+	return globalGDI.Shutdown(ctx)
+}
+
+// lifecycleKey 只有非空指针才能作为对象的标识
+func lifecycleKey(v reflect.Value) (interface{}, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Ptr || v.IsNil() || !v.CanInterface() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+// addDependency 记录 v 的属性 fieldName 依赖的对象
+func (gdi *GDIPool) addDependency(v reflect.Value, fieldName string, field reflect.Value) {
+	k, ok := lifecycleKey(v)
+	if !ok {
+		return
+	}
+	if _, ok := lifecycleKey(field); !ok {
+		return
+	}
+	gdi.lc.lock.Lock()
+	defer gdi.lc.lock.Unlock()
+	gdi.lc.deps[k] = append(gdi.lc.deps[k], dependency{field: fieldName, value: field})
+}
+
+// owned 当前容器持有的对象(子容器不包含父容器的对象)
+func (gdi *GDIPool) owned() map[interface{}]reflect.Value {
+	objs := make(map[interface{}]reflect.Value)
+	gdi.ttvLocker.RLock()
+	defer gdi.ttvLocker.RUnlock()
+	for _, m := range []map[reflect.Type]reflect.Value{gdi.typeToValuesReadOnly, gdi.typeToValues} {
+		for _, v := range m {
+			if k, ok := lifecycleKey(v); ok {
+				objs[k] = v
+			}
+		}
+	}
+	for _, m := range []map[string]reflect.Value{gdi.namesToValuesReadOnly, gdi.namesToValues} {
+		for _, v := range m {
+			if k, ok := lifecycleKey(v); ok {
+				objs[k] = v
+			}
+		}
+	}
+	return objs
+}
+
+// lifecycleOrder 按依赖顺序(被依赖的在前)返回当前容器持有的对象
+func (gdi *GDIPool) lifecycleOrder() []reflect.Value {
+	owned := gdi.owned()
+	gdi.lc.lock.Lock()
+	defer gdi.lc.lock.Unlock()
+	var order []reflect.Value
+	visited := make(map[interface{}]bool)
+	var visit func(k interface{}, v reflect.Value)
+	visit = func(k interface{}, v reflect.Value) {
+		if visited[k] {
+			return
+		}
+		visited[k] = true
+		for _, d := range gdi.lc.deps[k] {
+			if dk, ok := lifecycleKey(d.value); ok {
+				visit(dk, d.value)
+			}
+		}
+		if _, ok := owned[k]; ok {
+			order = append(order, v)
+		}
+	}
+	for k, v := range owned {
+		visit(k, v)
+	}
+	return order
+}
+
+// afterInject 按依赖顺序调用 AfterInject，每个对象只调用一次
+func (gdi *GDIPool) afterInject() error {
+	for _, v := range gdi.lifecycleOrder() {
+		k, _ := lifecycleKey(v)
+		gdi.lc.lock.Lock()
+		started := gdi.lc.started[k]
+		gdi.lc.started[k] = true
+		gdi.lc.lock.Unlock()
+		if started {
+			continue
+		}
+		if initializer, ok := k.(Initializer); ok {
+			if err := initializer.AfterInject(); err != nil {
+				return fmt.Errorf("(ERROR) %v AfterInject fail %v", v.Type(), err)
+			}
+			gdi.log(fmt.Sprintf("call %v AfterInject success", v.Type()))
+		}
+	}
+	return nil
+}
+
+// Shutdown 关闭容器，按依赖逆序调用对象的 Close 方法，ctx 超时或取消时停止关闭剩余对象
+func (gdi *GDIPool) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	order := gdi.lifecycleOrder()
+	var msgs []string
+	for i := len(order) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			msgs = append(msgs, fmt.Sprintf("shutdown interrupted %v", err))
+			break
+		}
+		k, _ := lifecycleKey(order[i])
+		closer, ok := k.(Closer)
+		if !ok {
+			continue
+		}
+		gdi.lc.lock.Lock()
+		closed := gdi.lc.closed[k]
+		gdi.lc.closed[k] = true
+		gdi.lc.lock.Unlock()
+		if closed {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			msgs = append(msgs, fmt.Sprintf("close %v fail %v", order[i].Type(), err))
+			continue
+		}
+		gdi.log(fmt.Sprintf("call %v Close success", order[i].Type()))
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("(ERROR) %v", strings.Join(msgs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package gdi
+
+import (
+	"context"
+	"testing"
+)
+
+var lifecycleEvents []string
+
+type lcDB struct{}
+
+func (d *lcDB) AfterInject() error {
+	lifecycleEvents = append(lifecycleEvents, "init db")
+	return nil
+}
+
+func (d *lcDB) Close() error {
+	lifecycleEvents = append(lifecycleEvents, "close db")
+	return nil
+}
+
+type lcRepo struct {
+	DB *lcDB
+}
+
+func (r *lcRepo) AfterInject() error {
+	lifecycleEvents = append(lifecycleEvents, "init repo")
+	return nil
+}
+
+func (r *lcRepo) Close() error {
+	lifecycleEvents = append(lifecycleEvents, "close repo")
+	return nil
+}
+
+type lcService struct {
+	Repo *lcRepo
+}
+
+func (s *lcService) Close() error {
+	lifecycleEvents = append(lifecycleEvents, "close service")
+	return nil
+}
+
+func TestLifecycle(t *testing.T) {
+	lifecycleEvents = nil
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&lcService{}, &lcRepo{}, &lcDB{})
+	gp.Init()
+	gp.Init()
+	if err := gp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	gp.Shutdown(context.Background())
+	expect := []string{"init db", "init repo", "close service", "close repo", "close db"}
+	if len(lifecycleEvents) != len(expect) {
+		t.Fatalf("expect %v got %v", expect, lifecycleEvents)
+	}
+	for i := range expect {
+		if expect[i] != lifecycleEvents[i] {
+			t.Fatalf("expect %v got %v", expect, lifecycleEvents)
+		}
+	}
+}
+
+type lcCtorRepo struct {
+	db *lcDB
+}
+
+func (r *lcCtorRepo) AfterInject() error {
+	lifecycleEvents = append(lifecycleEvents, "init ctor repo")
+	return nil
+}
+
+func (r *lcCtorRepo) Close() error {
+	lifecycleEvents = append(lifecycleEvents, "close ctor repo")
+	return nil
+}
+
+func TestLifecycleConstructorDependency(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		lifecycleEvents = nil
+		gp := NewGDIPool()
+		gp.Debug(false)
+		gp.Register(func(db *lcDB) *lcCtorRepo {
+			return &lcCtorRepo{db: db}
+		}, &lcDB{})
+		if err := gp.InitE(); err != nil {
+			t.Fatal(err)
+		}
+		if err := gp.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		expect := []string{"init db", "init ctor repo", "close ctor repo", "close db"}
+		if len(lifecycleEvents) != len(expect) {
+			t.Fatalf("expect %v got %v", expect, lifecycleEvents)
+		}
+		for i := range expect {
+			if expect[i] != lifecycleEvents[i] {
+				t.Fatalf("expect %v got %v", expect, lifecycleEvents)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package gdi
+
+import (
+	"regexp"
+)
+
+// LogLevel 日志级别，取值与 log/slog 一致
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = -4
+	LevelInfo  LogLevel = 0
+	LevelWarn  LogLevel = 4
+	LevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch {
+	case l < LevelInfo:
+		return "DEBUG"
+	case l < LevelWarn:
+		return "INFO"
+	case l < LevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Logger 容器日志接口，*slog.Logger 可以直接使用，args 为 key-value 形式的结构化属性(event、type、field、fieldType、pkgPath 等)
+// Example：gdi.SetLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// 日志中的事件类型
+const (
+	eventRegister   = "register"
+	eventCreate     = "create"
+	eventInject     = "inject"
+	eventAutoCreate = "autoCreate"
+	eventError      = "error"
+)
+
+var ansiColor = regexp.MustCompile("\u001B\\[[0-9;]*m")
+
+// SetLogger 设定容器的日志输出，nil 表示输出到控制台
+func SetLogger(logger Logger) {
+	globalGDI.SetLogger(logger)
+}
+
+// SetLogLevel 设定日志级别，低于该级别的日志不输出 default:LevelInfo
+func SetLogLevel(level LogLevel) {
+	globalGDI.SetLogLevel(level)
+}
+
+// NoColor 控制台日志不输出颜色
+func NoColor(noColor bool) {
+	globalGDI.NoColor(noColor)
+}
+
+// SetLogger 设定容器的日志输出，nil 表示输出到控制台
+func (gdi *GDIPool) SetLogger(logger Logger) {
+	gdi.logger = logger
+}
+
+// SetLogLevel 设定日志级别，低于该级别的日志不输出 default:LevelInfo
+func (gdi *GDIPool) SetLogLevel(level LogLevel) {
+	gdi.logLevel = level
+}
+
+// NoColor 控制台日志不输出颜色
+func (gdi *GDIPool) NoColor(noColor bool) {
+	gdi.noColor = noColor
+}
+
+// output 按级别输出日志，自定义 Logger 及无颜色模式下去掉消息中的颜色
+func (gdi *GDIPool) output(level LogLevel, color string, prefix string, msg string, args ...interface{}) {
+	if level < gdi.logLevel {
+		return
+	}
+	if gdi.logger != nil {
+		msg = ansiColor.ReplaceAllString(msg, "")
+		switch {
+		case level < LevelInfo:
+			gdi.logger.Debug(msg, args...)
+		case level < LevelWarn:
+			gdi.logger.Info(msg, args...)
+		case level < LevelError:
+			gdi.logger.Warn(msg, args...)
+		default:
+			gdi.logger.Error(msg, args...)
+		}
+		return
+	}
+	if gdi.noColor {
+		gdi.consoleLog.Println(prefix + ansiColor.ReplaceAllString(msg, ""))
+		return
+	}
+	gdi.consoleLog.Println(color + prefix + msg + "\033[0m")
+}
@@ -0,0 +1,100 @@
+package gdi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type logRecord struct {
+	level string
+	msg   string
+	attrs map[string]interface{}
+}
+
+type fakeLogger struct {
+	records []logRecord
+}
+
+func (l *fakeLogger) add(level string, msg string, args ...any) {
+	attrs := make(map[string]interface{})
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[fmt.Sprint(args[i])] = args[i+1]
+	}
+	l.records = append(l.records, logRecord{level: level, msg: msg, attrs: attrs})
+}
+
+func (l *fakeLogger) Debug(msg string, args ...any) { l.add("DEBUG", msg, args...) }
+func (l *fakeLogger) Info(msg string, args ...any)  { l.add("INFO", msg, args...) }
+func (l *fakeLogger) Warn(msg string, args ...any)  { l.add("WARN", msg, args...) }
+func (l *fakeLogger) Error(msg string, args ...any) { l.add("ERROR", msg, args...) }
+
+type logDep struct{}
+
+type logService struct {
+	Dep  *logDep
+	Auto *logAuto
+}
+
+type logAuto struct{}
+
+type logCtor struct{}
+
+type logProto struct{}
+
+func TestSetLogger(t *testing.T) {
+	logger := &fakeLogger{}
+	gp := NewGDIPool()
+	gp.SetLogger(logger)
+	gp.Register(&logDep{}, &logService{})
+	gp.RegisterReadOnly(func() *logCtor { return &logCtor{} })
+	gp.RegisterPrototype(&logProto{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	var inject, autoCreate, register bool
+	registered := make(map[interface{}]interface{})
+	for _, r := range logger.records {
+		if strings.Contains(r.msg, "\u001B[") {
+			t.Fatalf("color should be removed: %q", r.msg)
+		}
+		switch r.attrs["event"] {
+		case eventInject:
+			inject = inject || r.attrs["field"] == "Dep" && r.attrs["type"] == "*gdi.logService" && r.attrs["fieldType"] == "*gdi.logDep"
+		case eventAutoCreate:
+			autoCreate = r.level == "WARN" && r.attrs["type"] == "*gdi.logAuto"
+		case eventRegister:
+			register = register || r.attrs["type"] == "*gdi.logDep"
+			registered[r.attrs["type"]] = r.attrs["pkgPath"]
+		}
+	}
+	for _, typ := range []string{"*gdi.logCtor", "*gdi.logProto"} {
+		if registered[typ] != "github.com/sjqzhang/gdi" {
+			t.Fatalf("missing register event of %v: %v", typ, registered)
+		}
+	}
+	if !inject || !autoCreate || !register {
+		t.Fatalf("missing structured events inject:%v autoCreate:%v register:%v", inject, autoCreate, register)
+	}
+
+	logger.records = nil
+	gp.SetLogLevel(LevelError)
+	gp.log("info")
+	gp.warn("warn")
+	gp.error("error")
+	if len(logger.records) != 1 || logger.records[0].level != "ERROR" {
+		t.Fatalf("log level should filter records, got %v", logger.records)
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	var buf bytes.Buffer
+	gp := NewGDIPool()
+	gp.consoleLog.SetOutput(&buf)
+	gp.NoColor(true)
+	gp.warn("\u001B[1;35mautoCreate\u001B[0m type")
+	if strings.Contains(buf.String(), "\u001B[") || !strings.Contains(buf.String(), "WARNNING: autoCreate type") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
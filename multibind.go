@@ -0,0 +1,139 @@
+package gdi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Orderer 多实现注入(inject:"all")时，实现了 Order 的对象按返回值从小到大排序，未实现的视为0；
+// 结构体中有 inject:"order:N" 标签的属性(如 _ struct{} `inject:"order:10"`)时优先使用 N
+type Orderer interface {
+	Order() int
+}
+
+// binding 容器中的一个对象及其注册名称(按类型注册时为类型名)
+type binding struct {
+	name  string
+	value reflect.Value
+}
+
+// allNamed 获取容器(包括父容器)中按名称注册的对象
+func (gdi *GDIPool) allNamed() map[string]reflect.Value {
+	objs := make(map[string]reflect.Value)
+	if gdi.parent != nil {
+		objs = gdi.parent.allNamed()
+	}
+	gdi.ttvLocker.RLock()
+	defer gdi.ttvLocker.RUnlock()
+	for _, m := range []map[string]reflect.Value{gdi.namesToValuesReadOnly, gdi.namesToValues} {
+		for k, v := range m {
+			objs[k] = v
+		}
+	}
+	return objs
+}
+
+// allReadOnly 获取容器(包括父容器)中按类型注册的只读对象
+func (gdi *GDIPool) allReadOnly() map[reflect.Type]reflect.Value {
+	objs := make(map[reflect.Type]reflect.Value)
+	if gdi.parent != nil {
+		objs = gdi.parent.allReadOnly()
+	}
+	gdi.ttvLocker.RLock()
+	defer gdi.ttvLocker.RUnlock()
+	for k, v := range gdi.typeToValuesReadOnly {
+		objs[k] = v
+	}
+	return objs
+}
+
+// getAllByType 获取容器中所有可以赋值给 t 的对象，按 Order 和名称排序，同一对象只返回一次
+func (gdi *GDIPool) getAllByType(t reflect.Type) []binding {
+	var bindings []binding
+	seen := make(map[interface{}]bool)
+	add := func(name string, v reflect.Value) {
+		if !v.IsValid() || !v.Type().AssignableTo(t) {
+			return
+		}
+		if k, ok := lifecycleKey(v); ok {
+			if seen[k] {
+				return
+			}
+			seen[k] = true
+		}
+		bindings = append(bindings, binding{name: name, value: v})
+	}
+	for _, m := range []map[reflect.Type]reflect.Value{gdi.allReadOnly(), gdi.all()} {
+		for k, v := range m {
+			add(k.String(), v)
+		}
+	}
+	for name, v := range gdi.allNamed() {
+		add(name, v)
+	}
+	sort.SliceStable(bindings, func(i, j int) bool {
+		oi, oj := gdi.order(bindings[i].value), gdi.order(bindings[j].value)
+		if oi != oj {
+			return oi < oj
+		}
+		return bindings[i].name < bindings[j].name
+	})
+	return bindings
+}
+
+// order 多实现注入时的排序值，inject:"order:N" 标签优先于 Orderer
+func (gdi *GDIPool) order(v reflect.Value) int {
+	t := v.Type()
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		t = v.Elem().Type()
+	}
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		for i := 0; i < t.Elem().NumField(); i++ {
+			f := t.Elem().Field(i)
+			if s, ok := gdi.getTagAttr(f, "order"); ok {
+				n, err := strconv.Atoi(s)
+				if err != nil {
+					gdi.warn(fmt.Sprintf("invalid order:%v fieldName:%v of %v", s, f.Name, t), "type", t.String(), "field", f.Name)
+					break
+				}
+				return n
+			}
+		}
+	}
+	if o, ok := v.Interface().(Orderer); ok {
+		return o.Order()
+	}
+	return 0
+}
+
+// injectAll 将所有实现注入到 []T 或 map[string]T 类型的属性中
+func (gdi *GDIPool) injectAll(v reflect.Value, fieldName string, field reflect.Value, n *node, i int) error {
+	ft := field.Type()
+	if ft.Kind() == reflect.Map && ft.Key().Kind() != reflect.String {
+		return fmt.Errorf("inject:\"all\" just support []T or map[string]T, got %v", ft)
+	}
+	bindings := gdi.getAllByType(ft.Elem())
+	var result reflect.Value
+	if ft.Kind() == reflect.Slice {
+		result = reflect.MakeSlice(ft, 0, len(bindings))
+	} else {
+		result = reflect.MakeMapWithSize(ft, len(bindings))
+	}
+	self, _ := lifecycleKey(v)
+	for _, b := range bindings {
+		if k, ok := lifecycleKey(b.value); ok && k == self {
+			continue
+		}
+		if ft.Kind() == reflect.Slice {
+			result = reflect.Append(result, b.value)
+		} else {
+			result.SetMapIndex(reflect.ValueOf(b.name).Convert(ft.Key()), b.value)
+		}
+		gdi.addDependency(v, fieldName, b.value)
+		n.addEdge(&edge{from: v.Type().String(), index: i, field: fieldName, to: b.value.Type().String()})
+	}
+	field.Set(result)
+	return nil
+}
@@ -0,0 +1,99 @@
+package gdi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mbValidator interface {
+	Validate(s string) bool
+}
+
+type mbNotEmpty struct{}
+
+func (v *mbNotEmpty) Validate(s string) bool { return s != "" }
+func (v *mbNotEmpty) Order() int             { return 2 }
+
+type mbMaxLen struct{}
+
+func (v *mbMaxLen) Validate(s string) bool { return len(s) < 10 }
+func (v *mbMaxLen) Order() int             { return 1 }
+
+type mbNoSpace struct{}
+
+func (v *mbNoSpace) Validate(s string) bool { return !strings.Contains(s, " ") }
+
+type mbChain struct {
+	List []mbValidator          `inject:"all"`
+	Map  map[string]mbValidator `inject:"all"`
+}
+
+func (c *mbChain) Validate(s string) bool {
+	for _, v := range c.List {
+		if !v.Validate(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestInjectAll(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&mbNotEmpty{}, &mbMaxLen{}, &mbChain{})
+	gp.Register(func() (*mbNoSpace, string) {
+		return &mbNoSpace{}, "nospace"
+	})
+	gp.Init()
+	c := MustResolve[*mbChain](gp)
+	if len(c.List) != 3 || len(c.Map) != 3 {
+		t.Fatalf("expect 3 validators got %v %v", c.List, c.Map)
+	}
+	if _, ok := c.List[0].(*mbNoSpace); !ok {
+		t.Fatalf("unexpected order %#v", c.List)
+	}
+	if _, ok := c.List[1].(*mbMaxLen); !ok {
+		t.Fatalf("unexpected order %#v", c.List)
+	}
+	if _, ok := c.Map["nospace"]; !ok {
+		t.Fatal("named implementation should be keyed by name")
+	}
+	if _, ok := c.Map["*gdi.mbMaxLen"]; !ok {
+		t.Fatal("typed implementation should be keyed by type")
+	}
+	if !c.Validate("abc") || c.Validate("a b") {
+		t.Fatal("chain validate fail")
+	}
+}
+
+type mbPriority struct {
+	_ struct{} `inject:"order:0"`
+}
+
+func (v *mbPriority) Validate(s string) bool { return true }
+func (v *mbPriority) Order() int             { return 100 }
+
+type mbLast struct {
+	_ struct{} `inject:"order:10"`
+}
+
+func (v *mbLast) Validate(s string) bool { return true }
+
+func TestInjectAllOrderTag(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&mbLast{}, &mbNotEmpty{}, &mbMaxLen{}, &mbPriority{}, &mbChain{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	c := MustResolve[*mbChain](gp)
+	var names []string
+	for _, v := range c.List {
+		names = append(names, reflect.TypeOf(v).String())
+	}
+	expect := "*gdi.mbPriority,*gdi.mbMaxLen,*gdi.mbNotEmpty,*gdi.mbLast"
+	if strings.Join(names, ",") != expect {
+		t.Fatalf("expect %v got %v", expect, names)
+	}
+}
@@ -0,0 +1,356 @@
+package gdi
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// TestingT testing.T 与 testing.B 的子集，避免依赖 testing 包
+type TestingT interface {
+	Helper()
+	Cleanup(func())
+}
+
+// Snapshot 容器的快照，用于 Override 之后通过 Restore 还原
+type Snapshot struct {
+	typeToValues          map[reflect.Type]reflect.Value
+	typeToValuesReadOnly  map[reflect.Type]reflect.Value
+	typeToValuesForTest   map[reflect.Type]reflect.Value
+	namesToValues         map[string]reflect.Value
+	namesToValuesReadOnly map[string]reflect.Value
+	creator               map[reflect.Type]interface{}
+	invoked               map[reflect.Type]bool
+	qualifiers            map[reflect.Type]map[string]reflect.Type
+	deps                  map[interface{}][]dependency
+	fields                []fieldSnapshot
+}
+
+// fieldSnapshot 已注入属性的值
+type fieldSnapshot struct {
+	field reflect.Value
+	value reflect.Value
+}
+
+// Override 使用 mock 替换已注册的对象，并重新注入所有依赖它的属性，real 可以是对象指针、类型 (*T)(nil)、接口 (*I)(nil) 或注册名称
+// Example：gdi.Override((*UserRepo)(nil), &UserRepo{DB: fakeDB})
+func Override(real interface{}, mock interface{}) error {
+	return globalGDI.Override(real, mock)
+}
+
+// TakeSnapshot 获取全局容器的快照
+func TakeSnapshot() *Snapshot {
+	return globalGDI.Snapshot()
+}
+
+// Restore 将全局容器还原到快照时的状态
+func Restore(s *Snapshot) {
+	globalGDI.Restore(s)
+}
+
+// NewTestPool 复制全局容器中的对象(浅拷贝)及注册信息，返回互不影响的容器，Override 等修改只作用于返回的容器，
+// 不修改全局容器，因此可以在 t.Parallel() 的测试中使用，测试结束后丢弃即可
+// Example：pool := gdi.NewTestPool(t); pool.Override((*Repo)(nil), &FakeRepo{})
+func NewTestPool(t TestingT) *GDIPool {
+	t.Helper()
+	return globalGDI.clone()
+}
+
+// copyMap 复制 map
+func copyMap(m interface{}) interface{} {
+	v := reflect.ValueOf(m)
+	result := reflect.MakeMapWithSize(v.Type(), v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		result.SetMapIndex(iter.Key(), iter.Value())
+	}
+	return result.Interface()
+}
+
+// settableField 获取对象的属性，非公开属性也可以设置
+func settableField(owner interface{}, fieldName string) (reflect.Value, bool) {
+	v := reflect.ValueOf(owner)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	field := v.Elem().FieldByName(fieldName)
+	if !field.IsValid() {
+		return reflect.Value{}, false
+	}
+	if !field.CanSet() {
+		field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+	}
+	return field, true
+}
+
+// Snapshot 获取容器的快照，包括注册的对象及已注入的属性
+func (gdi *GDIPool) Snapshot() *Snapshot {
+	gdi.creatorLocker.RLock()
+	defer gdi.creatorLocker.RUnlock()
+	gdi.ttvLocker.RLock()
+	defer gdi.ttvLocker.RUnlock()
+	gdi.lc.lock.Lock()
+	defer gdi.lc.lock.Unlock()
+	s := &Snapshot{
+		typeToValues:          copyMap(gdi.typeToValues).(map[reflect.Type]reflect.Value),
+		typeToValuesReadOnly:  copyMap(gdi.typeToValuesReadOnly).(map[reflect.Type]reflect.Value),
+		typeToValuesForTest:   copyMap(gdi.typeToValuesForTest).(map[reflect.Type]reflect.Value),
+		namesToValues:         copyMap(gdi.namesToValues).(map[string]reflect.Value),
+		namesToValuesReadOnly: copyMap(gdi.namesToValuesReadOnly).(map[string]reflect.Value),
+		creator:               copyMap(gdi.creator).(map[reflect.Type]interface{}),
+		invoked:               copyMap(gdi.invoked).(map[reflect.Type]bool),
+		qualifiers:            make(map[reflect.Type]map[string]reflect.Type),
+		deps:                  make(map[interface{}][]dependency),
+	}
+	for i, m := range gdi.qualifiers {
+		s.qualifiers[i] = copyMap(m).(map[string]reflect.Type)
+	}
+	for k, deps := range gdi.lc.deps {
+		s.deps[k] = append([]dependency{}, deps...)
+		for _, d := range deps {
+			if d.value.CanSet() {
+				value := reflect.New(d.value.Type()).Elem()
+				value.Set(d.value)
+				s.fields = append(s.fields, fieldSnapshot{field: d.value, value: value})
+			}
+		}
+	}
+	return s
+}
+
+// Restore 将容器还原到快照时的状态，被 Override 替换的属性会重新指向原来的对象
+func (gdi *GDIPool) Restore(s *Snapshot) {
+	if s == nil {
+		return
+	}
+	gdi.lock.Lock()
+	defer gdi.lock.Unlock()
+	gdi.creatorLocker.Lock()
+	defer gdi.creatorLocker.Unlock()
+	gdi.ttvLocker.Lock()
+	defer gdi.ttvLocker.Unlock()
+	gdi.lc.lock.Lock()
+	defer gdi.lc.lock.Unlock()
+	gdi.typeToValues = copyMap(s.typeToValues).(map[reflect.Type]reflect.Value)
+	gdi.typeToValuesReadOnly = copyMap(s.typeToValuesReadOnly).(map[reflect.Type]reflect.Value)
+	gdi.typeToValuesForTest = copyMap(s.typeToValuesForTest).(map[reflect.Type]reflect.Value)
+	gdi.namesToValues = copyMap(s.namesToValues).(map[string]reflect.Value)
+	gdi.namesToValuesReadOnly = copyMap(s.namesToValuesReadOnly).(map[string]reflect.Value)
+	gdi.creator = copyMap(s.creator).(map[reflect.Type]interface{})
+	gdi.invoked = copyMap(s.invoked).(map[reflect.Type]bool)
+	gdi.qualifiers = make(map[reflect.Type]map[string]reflect.Type)
+	for i, m := range s.qualifiers {
+		gdi.qualifiers[i] = copyMap(m).(map[string]reflect.Type)
+	}
+	gdi.lc.deps = make(map[interface{}][]dependency)
+	for k, deps := range s.deps {
+		gdi.lc.deps[k] = append([]dependency{}, deps...)
+	}
+	for _, f := range s.fields {
+		f.field.Set(f.value)
+	}
+}
+
+// Override 使用 mock 替换已注册的对象，并重新注入所有依赖它的属性，real 可以是对象指针、类型 (*T)(nil)、接口 (*I)(nil) 或注册名称
+// Example：gdi.Override((*UserRepo)(nil), &UserRepo{DB: fakeDB})
+func (gdi *GDIPool) Override(real interface{}, mock interface{}) error {
+	mv := reflect.ValueOf(mock)
+	if mv.Kind() != reflect.Ptr || mv.IsNil() {
+		return fmt.Errorf("(ERROR) mock must be a non-nil Ptr")
+	}
+	gdi.lock.Lock()
+	defer gdi.lock.Unlock()
+	if name, ok := real.(string); ok {
+		gdi.ttvLocker.Lock()
+		old, ok := gdi.namesToValues[name]
+		if ok {
+			gdi.namesToValues[name] = mv
+		} else if old, ok = gdi.namesToValuesReadOnly[name]; ok {
+			gdi.namesToValuesReadOnly[name] = mv
+		}
+		gdi.ttvLocker.Unlock()
+		if !ok {
+			return fmt.Errorf("(ERROR) name:%v object not found", name)
+		}
+		gdi.rewire(old, mv)
+		gdi.log(fmt.Sprintf("override name:%v with %v", name, mv.Type()))
+		return nil
+	}
+	rt := reflect.TypeOf(real)
+	if rt == nil || rt.Kind() != reflect.Ptr {
+		return fmt.Errorf("(ERROR) real must be a Ptr, a pointer to interface or a name")
+	}
+	if rt.Elem().Kind() == reflect.Interface {
+		i := rt.Elem()
+		if !mv.Type().Implements(i) {
+			return fmt.Errorf("(ERROR) %v not impliment %v", mv.Type(), i)
+		}
+		gdi.creatorLocker.Lock()
+		if _, ok := gdi.qualifiers[i]; !ok {
+			gdi.qualifiers[i] = make(map[string]reflect.Type)
+		}
+		gdi.qualifiers[i][""] = mv.Type()
+		gdi.creatorLocker.Unlock()
+		gdi.replace(mv.Type(), mv)
+		gdi.lc.lock.Lock()
+		for _, deps := range gdi.lc.deps {
+			for _, d := range deps {
+				if d.value.Type() == i && d.value.CanSet() {
+					d.value.Set(mv)
+				}
+			}
+		}
+		gdi.lc.lock.Unlock()
+		gdi.log(fmt.Sprintf("override interface:%v with %v", i, mv.Type()))
+		return nil
+	}
+	if !mv.Type().AssignableTo(rt) {
+		return fmt.Errorf("(ERROR) %v can not override %v", mv.Type(), rt)
+	}
+	old := gdi.replace(rt, mv)
+	gdi.rewire(old, mv)
+	gdi.log(fmt.Sprintf("override type:%v with %v", rt, mv.Type()))
+	return nil
+}
+
+// replace 替换按类型注册的对象，返回原来的对象，未注册时直接注册 mock
+func (gdi *GDIPool) replace(t reflect.Type, mv reflect.Value) (old reflect.Value) {
+	gdi.creatorLocker.Lock()
+	defer gdi.creatorLocker.Unlock()
+	gdi.ttvLocker.Lock()
+	defer gdi.ttvLocker.Unlock()
+	if _, ok := gdi.creator[t]; ok {
+		gdi.invoked[t] = true
+	}
+	var ok bool
+	if old, ok = gdi.typeToValuesReadOnly[t]; ok {
+		gdi.typeToValuesReadOnly[t] = mv
+		return old
+	}
+	old = gdi.typeToValues[t]
+	gdi.typeToValues[t] = mv
+	return old
+}
+
+// rewire 将所有注入了 old 的属性重新指向 mv
+func (gdi *GDIPool) rewire(old reflect.Value, mv reflect.Value) {
+	oldKey, ok := lifecycleKey(old)
+	if !ok {
+		return
+	}
+	gdi.lc.lock.Lock()
+	defer gdi.lc.lock.Unlock()
+	for _, deps := range gdi.lc.deps {
+		for _, d := range deps {
+			if k, ok := lifecycleKey(d.value); ok && k == oldKey && d.value.CanSet() && mv.Type().AssignableTo(d.value.Type()) {
+				d.value.Set(mv)
+			}
+		}
+	}
+}
+
+// clone 复制容器，对象为浅拷贝，对象之间的注入关系指向复制后的对象
+func (gdi *GDIPool) clone() *GDIPool {
+	pool := NewGDIPool()
+	pool.debug = gdi.debug
+	pool.logger = gdi.logger
+	pool.logLevel = gdi.logLevel
+	pool.noColor = gdi.noColor
+	pool.consoleLog = gdi.consoleLog
+	pool.graphExporters = gdi.copyGraphExporters()
+	gdi.listenerLocker.RLock()
+	pool.listeners = append([]Listener{}, gdi.listeners...)
+	gdi.listenerLocker.RUnlock()
+	pool.autoCreate = gdi.autoCreate
+	pool.ignorePrivate = gdi.ignorePrivate
+	pool.ignoreInterface = gdi.ignoreInterface
+	pool.strict = gdi.strict
+	pool.fs = gdi.fs
+	s := gdi.Snapshot()
+	gdi.creatorLocker.RLock()
+	pool.prototypes = copyMap(gdi.prototypes).(map[reflect.Type]interface{})
+	pool.scoped = copyMap(gdi.scoped).(map[reflect.Type]interface{})
+	pool.interfaceToImplements = copyMap(gdi.interfaceToImplements).(map[string]string)
+	pool.allowedCycles = copyMap(gdi.allowedCycles).(map[string]bool)
+	pool.config = copyMap(gdi.config).(map[string]string)
+	pool.profiles = append([]string{}, gdi.profiles...)
+	pool.decorators = append([]interface{}{}, gdi.decorators...)
+	gdi.creatorLocker.RUnlock()
+	gdi.lc.lock.Lock()
+	started := copyMap(gdi.lc.started).(map[interface{}]bool)
+	gdi.lc.lock.Unlock()
+
+	clones := make(map[interface{}]reflect.Value)
+	cloneOf := func(v reflect.Value) reflect.Value {
+		k, ok := lifecycleKey(v)
+		if !ok {
+			return v
+		}
+		if c, ok := clones[k]; ok {
+			return c
+		}
+		ptr := reflect.ValueOf(k)
+		if ptr.Elem().Kind() != reflect.Struct {
+			return v
+		}
+		c := reflect.New(ptr.Elem().Type())
+		c.Elem().Set(ptr.Elem())
+		clones[k] = c
+		return c
+	}
+	for t, v := range s.typeToValues {
+		pool.typeToValues[t] = cloneOf(v)
+	}
+	for t, v := range s.typeToValuesReadOnly {
+		pool.typeToValuesReadOnly[t] = cloneOf(v)
+	}
+	for t, v := range s.typeToValuesForTest {
+		pool.typeToValuesForTest[t] = v
+	}
+	for name, v := range s.namesToValues {
+		pool.namesToValues[name] = cloneOf(v)
+	}
+	for name, v := range s.namesToValuesReadOnly {
+		pool.namesToValuesReadOnly[name] = cloneOf(v)
+	}
+	pool.creator = s.creator
+	pool.invoked = s.invoked
+	pool.qualifiers = s.qualifiers
+	for k, deps := range s.deps {
+		owner, ok := clones[k]
+		if !ok {
+			continue
+		}
+		obj := owner.Interface()
+		if started[k] {
+			pool.lc.started[obj] = true
+		}
+		for _, d := range deps {
+			field, ok := settableField(obj, d.field)
+			if !ok { // 构造函数的参数不是属性，只保留依赖关系
+				if dk, ok := lifecycleKey(d.value); ok {
+					if c, ok := clones[dk]; ok {
+						pool.lc.deps[obj] = append(pool.lc.deps[obj], dependency{field: d.field, value: c})
+					}
+				}
+				continue
+			}
+			if dk, ok := lifecycleKey(d.value); ok {
+				if c, ok := clones[dk]; ok && c.Type().AssignableTo(field.Type()) {
+					field.Set(c)
+				}
+			}
+			pool.lc.deps[obj] = append(pool.lc.deps[obj], dependency{field: d.field, value: field})
+		}
+	}
+	gdi.ttvLocker.RLock()
+	for k, p := range gdi.provenance {
+		if c, ok := clones[k]; ok {
+			pool.provenance[c.Interface()] = p
+		} else {
+			pool.provenance[k] = p
+		}
+	}
+	gdi.ttvLocker.RUnlock()
+	return pool
+}
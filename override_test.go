@@ -0,0 +1,123 @@
+package gdi
+
+import (
+	"fmt"
+	"testing"
+)
+
+type ovRepo interface {
+	Find() string
+}
+
+type ovDB struct {
+	DSN string
+}
+
+type ovMySQLRepo struct {
+	DB *ovDB
+}
+
+func (r *ovMySQLRepo) Find() string { return "mysql:" + r.DB.DSN }
+
+type ovFakeRepo struct{}
+
+func (r *ovFakeRepo) Find() string { return "fake" }
+
+type ovService struct {
+	DB    *ovDB
+	Repo  ovRepo
+	Named *ovDB `inject:"name:replica"`
+}
+
+func newOverridePool(t *testing.T) *GDIPool {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&ovDB{DSN: "prod"}, &ovMySQLRepo{}, &ovService{}, func() (*ovDB, string) {
+		return &ovDB{DSN: "replica"}, "replica"
+	})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	return gp
+}
+
+func TestOverride(t *testing.T) {
+	gp := newOverridePool(t)
+	svc := gp.Get(&ovService{}).(*ovService)
+	repo := gp.Get(&ovMySQLRepo{}).(*ovMySQLRepo)
+	s := gp.Snapshot()
+
+	mockDB := &ovDB{DSN: "test"}
+	if err := gp.Override((*ovDB)(nil), mockDB); err != nil {
+		t.Fatal(err)
+	}
+	if svc.DB != mockDB || repo.DB != mockDB || gp.Get(&ovDB{}) != mockDB {
+		t.Fatal("override should rewire injected fields")
+	}
+	if err := gp.Override((*ovRepo)(nil), &ovFakeRepo{}); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Repo.Find() != "fake" {
+		t.Fatalf("expect fake repo, got %v", svc.Repo.Find())
+	}
+	replica := &ovDB{DSN: "mock replica"}
+	if err := gp.Override("replica", replica); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Named != replica {
+		t.Fatal("override by name should rewire injected fields")
+	}
+	if err := gp.Override((*ovDB)(nil), &ovFakeRepo{}); err == nil {
+		t.Fatal("override should check the mock type")
+	}
+	if err := gp.Override("not exists", replica); err == nil {
+		t.Fatal("override should check the name")
+	}
+
+	gp.Restore(s)
+	if svc.DB.DSN != "prod" || repo.DB.DSN != "prod" || svc.Repo.Find() != "mysql:prod" || svc.Named.DSN != "replica" {
+		t.Fatal("restore should rewire injected fields back")
+	}
+	if gp.Get(&ovDB{}).(*ovDB).DSN != "prod" {
+		t.Fatal("restore should restore registrations")
+	}
+}
+
+func TestNewTestPool(t *testing.T) {
+	old := globalGDI
+	globalGDI = newOverridePool(t)
+	defer func() {
+		globalGDI = old
+	}()
+	base := globalGDI
+	svc := Get(&ovService{}).(*ovService)
+
+	t.Run("group", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			dsn := fmt.Sprintf("test%v", i)
+			t.Run(dsn, func(t *testing.T) {
+				t.Parallel()
+				pool := NewTestPool(t)
+				if err := pool.Override((*ovRepo)(nil), &ovFakeRepo{}); err != nil {
+					t.Fatal(err)
+				}
+				if err := pool.Override((*ovDB)(nil), &ovDB{DSN: dsn}); err != nil {
+					t.Fatal(err)
+				}
+				testSvc := pool.Get(&ovService{}).(*ovService)
+				if testSvc == svc {
+					t.Fatal("test pool should clone objects")
+				}
+				if testSvc.Repo.Find() != "fake" || testSvc.DB != pool.Get(&ovDB{}) || testSvc.DB.DSN != dsn {
+					t.Fatal("cloned objects should be rewired")
+				}
+				if svc.Repo.Find() != "mysql:prod" || svc.DB.DSN != "prod" {
+					t.Fatal("global pool should not be changed")
+				}
+			})
+		}
+	})
+	if globalGDI != base || Get(&ovService{}) != svc {
+		t.Fatal("global pool should not be replaced by test pools")
+	}
+}
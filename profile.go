@@ -0,0 +1,198 @@
+package gdi
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// profilesEnv 通过环境变量设定激活的环境，多个使用逗号分隔，如 GDI_PROFILES=prod,mysql
+const profilesEnv = "GDI_PROFILES"
+
+// defaultProfile 没有激活任何环境时，default 视为激活
+const defaultProfile = "default"
+
+// pendingRegistration 按环境注册的对象，在 Init 时根据激活的环境决定是否生效
+type pendingRegistration struct {
+	profiles []string
+	apply    func() error
+}
+
+// ProfileRegistrar 只在指定环境激活时生效的注册器
+type ProfileRegistrar struct {
+	gdi      *GDIPool
+	profiles []string
+}
+
+// SetProfiles 设定激活的环境，默认从环境变量 GDI_PROFILES 中读取
+func SetProfiles(profiles ...string) {
+	globalGDI.SetProfiles(profiles...)
+}
+
+// Profile 返回只在指定环境激活时生效的注册器，profile 以!开头表示该环境未激活时生效
+// Example：gdi.Profile("prod").Register(NewRedisCache)
+func Profile(profiles ...string) *ProfileRegistrar {
+	return globalGDI.Profile(profiles...)
+}
+
+// RegisterProfile 注册只在指定环境激活时生效的对象
+func RegisterProfile(profile string, funcObjOrPtrs ...interface{}) {
+	globalGDI.RegisterProfile(profile, funcObjOrPtrs...)
+}
+
+// RegisterIf 条件为真时才注册对象
+func RegisterIf(cond bool, funcObjOrPtrs ...interface{}) {
+	globalGDI.RegisterIf(cond, funcObjOrPtrs...)
+}
+
+func parseProfiles(profiles string) []string {
+	var result []string
+	for _, p := range strings.Split(profiles, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// SetProfiles 设定激活的环境，默认从环境变量 GDI_PROFILES 中读取
+func (gdi *GDIPool) SetProfiles(profiles ...string) {
+	gdi.creatorLocker.Lock()
+	defer gdi.creatorLocker.Unlock()
+	gdi.profiles = nil
+	for _, p := range profiles {
+		gdi.profiles = append(gdi.profiles, parseProfiles(p)...)
+	}
+}
+
+// ActiveProfiles 获取激活的环境
+func (gdi *GDIPool) ActiveProfiles() []string {
+	gdi.creatorLocker.RLock()
+	defer gdi.creatorLocker.RUnlock()
+	if len(gdi.profiles) == 0 {
+		return []string{defaultProfile}
+	}
+	return append([]string{}, gdi.profiles...)
+}
+
+// IsProfileActive 任意一个环境激活时返回true，profile 以!开头表示该环境未激活
+func (gdi *GDIPool) IsProfileActive(profiles ...string) bool {
+	active := make(map[string]bool)
+	for _, p := range gdi.ActiveProfiles() {
+		active[p] = true
+	}
+	for _, p := range profiles {
+		if strings.HasPrefix(p, "!") {
+			if !active[strings.TrimPrefix(p, "!")] {
+				return true
+			}
+		} else if active[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// Profile 返回只在指定环境激活时生效的注册器，profile 以!开头表示该环境未激活时生效
+// Example：gdi.Profile("prod").Register(NewRedisCache)
+func (gdi *GDIPool) Profile(profiles ...string) *ProfileRegistrar {
+	return &ProfileRegistrar{gdi: gdi, profiles: profiles}
+}
+
+// RegisterProfile 注册只在指定环境激活时生效的对象
+func (gdi *GDIPool) RegisterProfile(profile string, funcObjOrPtrs ...interface{}) {
+	gdi.Profile(profile).Register(funcObjOrPtrs...)
+}
+
+// RegisterIf 条件为真时才注册对象
+func (gdi *GDIPool) RegisterIf(cond bool, funcObjOrPtrs ...interface{}) {
+	if cond {
+		gdi.Register(funcObjOrPtrs...)
+	}
+}
+
+func (p *ProfileRegistrar) add(apply func() error) {
+	p.gdi.creatorLocker.Lock()
+	defer p.gdi.creatorLocker.Unlock()
+	p.gdi.pending = append(p.gdi.pending, pendingRegistration{profiles: p.profiles, apply: apply})
+}
+
+// Register 用于注册自己的业务代码，在 Init 时环境匹配才生效
+func (p *ProfileRegistrar) Register(funcObjOrPtrs ...interface{}) {
+	p.add(func() error {
+		p.gdi.Register(funcObjOrPtrs...)
+		return nil
+	})
+}
+
+// RegisterReadOnly 用于注册第三方代码，在 Init 时环境匹配才生效
+func (p *ProfileRegistrar) RegisterReadOnly(funcObjOrPtrs ...interface{}) {
+	p.add(func() error {
+		p.gdi.RegisterReadOnly(funcObjOrPtrs...)
+		return nil
+	})
+}
+
+// MapToImplement 设定接品与实现的映射关系，在 Init 时环境匹配才生效
+func (p *ProfileRegistrar) MapToImplement(pkgToFieldInteface interface{}, pkgImplement interface{}) error {
+	if reflect.TypeOf(pkgToFieldInteface).Kind() != reflect.Ptr || reflect.TypeOf(pkgImplement).Kind() != reflect.Ptr {
+		return fmt.Errorf("pkgToFieldInteface and pkgImplement must be a Ptr")
+	}
+	p.add(func() error {
+		return p.gdi.MapToImplement(pkgToFieldInteface, pkgImplement)
+	})
+	return nil
+}
+
+// Bind 设定接口的默认实现，在 Init 时环境匹配才生效
+func (p *ProfileRegistrar) Bind(iface interface{}, impl interface{}) {
+	p.add(func() error {
+		return p.gdi.Bind(iface, impl)
+	})
+}
+
+// AutoRegisterByPackagePatten 根据正则获取所有类型并自动注册，在 Init 时环境匹配才生效
+func (p *ProfileRegistrar) AutoRegisterByPackagePatten(packagePatten string) error {
+	if _, err := regexp.Compile(packagePatten); err != nil {
+		return err
+	}
+	p.add(func() error {
+		_, err := p.gdi.AutoRegisterByPackagePatten(packagePatten)
+		return err
+	})
+	return nil
+}
+
+// applyProfiles 执行环境匹配的注册，每个注册单独 recover(如重复注册)，出错时继续执行其余的注册
+func (gdi *GDIPool) applyProfiles() []error {
+	var errs []error
+	gdi.creatorLocker.Lock()
+	pending := gdi.pending
+	gdi.pending = nil
+	gdi.creatorLocker.Unlock()
+	for _, r := range pending {
+		if gdi.IsProfileActive(r.profiles...) {
+			gdi.log(fmt.Sprintf("apply profile %v registration", r.profiles))
+			if err := gdi.applyProfile(r); err != nil {
+				errs = append(errs, fmt.Errorf("profile %v: %v", r.profiles, err))
+			}
+		}
+	}
+	return errs
+}
+
+// applyProfile 执行一个注册，注册时的 panic 转换为错误
+func (gdi *GDIPool) applyProfile(r pendingRegistration) (e error) {
+	defer func() {
+		if err := recover(); err != nil {
+			e = fmt.Errorf("%v", err)
+		}
+	}()
+	return r.apply()
+}
+
+func profilesFromEnv() []string {
+	return parseProfiles(os.Getenv(profilesEnv))
+}
@@ -0,0 +1,115 @@
+package gdi
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+type pfCache interface {
+	Kind() string
+}
+
+type pfMemCache struct{}
+
+func (c *pfMemCache) Kind() string { return "memory" }
+
+type pfRedisCache struct{}
+
+func (c *pfRedisCache) Kind() string { return "redis" }
+
+type pfService struct {
+	Cache pfCache
+}
+
+func newProfilePool(profiles ...string) *GDIPool {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.SetProfiles(profiles...)
+	gp.Register(&pfService{})
+	gp.Profile("prod").Register(&pfRedisCache{})
+	gp.Profile("!prod").Register(&pfMemCache{})
+	return gp
+}
+
+func TestProfile(t *testing.T) {
+	for profiles, kind := range map[string]string{"prod": "redis", "dev,test": "memory", "": "memory"} {
+		gp := newProfilePool(profiles)
+		if err := gp.InitE(); err != nil {
+			t.Fatal(profiles, err)
+		}
+		svc := gp.Get(&pfService{}).(*pfService)
+		if svc.Cache == nil || svc.Cache.Kind() != kind {
+			t.Fatalf("profiles %q expect %v cache, got %v", profiles, kind, svc.Cache)
+		}
+	}
+
+	gp := NewGDIPool()
+	if got := gp.ActiveProfiles(); len(got) != 1 || got[0] != defaultProfile {
+		t.Fatalf("expect default profile, got %v", got)
+	}
+	if !gp.IsProfileActive("default") || gp.IsProfileActive("prod") {
+		t.Fatal("default profile should be active when no profile set")
+	}
+}
+
+func TestProfileFromEnv(t *testing.T) {
+	old, ok := os.LookupEnv(profilesEnv)
+	os.Setenv(profilesEnv, "test, prod")
+	defer func() {
+		if ok {
+			os.Setenv(profilesEnv, old)
+		} else {
+			os.Unsetenv(profilesEnv)
+		}
+	}()
+	gp := NewGDIPool()
+	if !gp.IsProfileActive("prod") || !gp.IsProfileActive("test") || gp.IsProfileActive("default") {
+		t.Fatalf("unexpected profiles %v", gp.ActiveProfiles())
+	}
+}
+
+func TestRegisterIf(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.RegisterIf(false, &pfRedisCache{})
+	gp.RegisterIf(true, &pfMemCache{})
+	gp.RegisterProfile("prod", &pfService{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gp.GetWithCheck(&pfRedisCache{}); ok {
+		t.Fatal("RegisterIf(false) should not register")
+	}
+	if _, ok := gp.GetWithCheck(&pfMemCache{}); !ok {
+		t.Fatal("RegisterIf(true) should register")
+	}
+	if _, ok := gp.GetWithCheck(&pfService{}); ok {
+		t.Fatal("prod profile is not active")
+	}
+	if err := gp.Profile("prod").MapToImplement(pfMemCache{}, &pfMemCache{}); err == nil {
+		t.Fatal("MapToImplement should check the arguments")
+	}
+}
+
+func TestProfileRegisterPanic(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.SetProfiles("prod")
+	gp.Register(&pfService{}, &pfMemCache{})
+	gp.Profile("prod").Register(&pfMemCache{})
+	gp.Profile("prod").Register(&pfRedisCache{})
+	gp.Bind((*pfCache)(nil), (*pfRedisCache)(nil))
+	err := gp.InitE()
+	var errs MultiError
+	if !errors.As(err, &errs) || len(errs) != 1 || !strings.Contains(errs[0].Error(), "double register") {
+		t.Fatalf("expect double register error only, got %v", err)
+	}
+	if _, ok := gp.GetWithCheck(&pfRedisCache{}); !ok {
+		t.Fatal("registrations after the failed one should be applied")
+	}
+	if svc := gp.Get(&pfService{}).(*pfService); svc.Cache == nil || svc.Cache.Kind() != "redis" {
+		t.Fatal("build should continue after a failed profile registration")
+	}
+}
@@ -0,0 +1,113 @@
+package gdi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Bind 设定接口的默认实现，没有标记 qualifier 的属性注入该实现 Example：gdi.Bind((*Repo)(nil), (*MySQLRepo)(nil))
+func Bind(iface interface{}, impl interface{}) error {
+	return globalGDI.Bind(iface, impl)
+}
+
+// BindQualified 设定接口在某个限定名下的实现，属性通过 inject:"qualifier:primary" 选择
+// Example：gdi.BindQualified((*Repo)(nil), "primary", (*MySQLRepo)(nil))
+func BindQualified(iface interface{}, qualifier string, impl interface{}) error {
+	return globalGDI.BindQualified(iface, qualifier, impl)
+}
+
+// Bind 设定接口的默认实现，没有标记 qualifier 的属性注入该实现 Example：gdi.Bind((*Repo)(nil), (*MySQLRepo)(nil))
+func (gdi *GDIPool) Bind(iface interface{}, impl interface{}) error {
+	return gdi.BindQualified(iface, "", impl)
+}
+
+// BindQualified 设定接口在某个限定名下的实现，属性通过 inject:"qualifier:primary" 选择
+// Example：gdi.BindQualified((*Repo)(nil), "primary", (*MySQLRepo)(nil))
+func (gdi *GDIPool) BindQualified(iface interface{}, qualifier string, impl interface{}) error {
+	it := reflect.TypeOf(iface)
+	if it == nil || it.Kind() != reflect.Ptr || it.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf("(ERROR) iface must be a pointer to interface, Example: (*Repo)(nil)")
+	}
+	implType := reflect.TypeOf(impl)
+	if implType == nil || implType.Kind() != reflect.Ptr {
+		return fmt.Errorf("(ERROR) impl must be a Ptr")
+	}
+	if !implType.Implements(it.Elem()) {
+		return fmt.Errorf("(ERROR) %v not impliment %v", implType, it.Elem())
+	}
+	gdi.creatorLocker.Lock()
+	defer gdi.creatorLocker.Unlock()
+	if _, ok := gdi.qualifiers[it.Elem()]; !ok {
+		gdi.qualifiers[it.Elem()] = make(map[string]reflect.Type)
+	}
+	gdi.qualifiers[it.Elem()][qualifier] = implType
+	return nil
+}
+
+func (gdi *GDIPool) getQualifiedType(i reflect.Type, qualifier string) (reflect.Type, bool) {
+	gdi.creatorLocker.RLock()
+	defer gdi.creatorLocker.RUnlock()
+	t, ok := gdi.qualifiers[i][qualifier]
+	return t, ok
+}
+
+// findImplType 按类型名查找实现，支持 pkg.MySQLRepo、*pkg.MySQLRepo 及完整包路径 github.com/x/pkg.MySQLRepo
+func (gdi *GDIPool) findImplType(i reflect.Type, implName string) (reflect.Type, bool) {
+	match := func(t reflect.Type) bool {
+		if !t.Implements(i) {
+			return false
+		}
+		name := t.String()
+		if name == implName || name == "*"+implName {
+			return true
+		}
+		if t.Kind() == reflect.Ptr && t.Elem().PkgPath()+"."+t.Elem().Name() == implName {
+			return true
+		}
+		return false
+	}
+	for t := range gdi.all() {
+		if match(t) {
+			return t, true
+		}
+	}
+	for t := range gdi.allTypesToValues {
+		if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && match(t) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// getQualified 按属性上的 impl/qualifier 标记或默认绑定获取接口的实现，handled 为 false 表示没有设定
+func (gdi *GDIPool) getQualified(i reflect.Type, f reflect.StructField, exitOnError bool, buildForTest bool, autoCreate bool) (value reflect.Value, handled bool, err error) {
+	var implType reflect.Type
+	if implName, ok := gdi.getTagAttr(f, "impl"); ok && implName != "" {
+		if implType, ok = gdi.findImplType(i, implName); !ok {
+			return reflect.Value{}, true, fmt.Errorf("impl:%v of interface %v not found", implName, i)
+		}
+	} else if qualifier, ok := gdi.getTagAttr(f, "qualifier"); ok && qualifier != "" {
+		if implType, ok = gdi.getQualifiedType(i, qualifier); !ok {
+			return reflect.Value{}, true, fmt.Errorf("qualifier:%v of interface %v not found, use gdi.BindQualified to bind it first", qualifier, i)
+		}
+	} else if value, ok := gdi.getDecorated(i); ok {
+		return value, true, nil
+	} else if implType, ok = gdi.getQualifiedType(i, ""); !ok {
+		return reflect.Value{}, false, nil
+	}
+	value, ok := gdi.resolve(implType)
+	if !ok {
+		if !autoCreate || implType.Elem().Kind() != reflect.Struct {
+			return reflect.Value{}, true, fmt.Errorf("%v of interface %v not register", implType, i)
+		}
+		value = reflect.New(implType.Elem())
+		gdi.warn(fmt.Sprintf("\u001B[1;35mautoCreate\u001B[0m  type:%v fieldName:%v", implType, f.Name), "event", eventAutoCreate, "type", implType.String(), "field", f.Name)
+		gdi.emitAutoCreate(AutoCreateEvent{Type: implType, Field: f.Name})
+		gdi.setAutoCreated(implType, value)
+		gdi.build(value, exitOnError, buildForTest)
+	}
+	if value, err = gdi.decorateImpl(i, implType, value); err != nil { // 接口装饰函数同样作用于选中的实现
+		return reflect.Value{}, true, err
+	}
+	return value, true, nil
+}
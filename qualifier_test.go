@@ -0,0 +1,49 @@
+package gdi
+
+import (
+	"testing"
+)
+
+type qRepo interface {
+	Name() string
+}
+
+type qMySQLRepo struct{}
+
+func (r *qMySQLRepo) Name() string { return "mysql" }
+
+type qRedisRepo struct{}
+
+func (r *qRedisRepo) Name() string { return "redis" }
+
+type qService struct {
+	Primary qRepo `inject:"qualifier:primary"`
+	Cache   qRepo `inject:"impl:gdi.qRedisRepo"`
+	Full    qRepo `inject:"impl:github.com/sjqzhang/gdi.qMySQLRepo"`
+	Default qRepo
+}
+
+func TestQualifier(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&qMySQLRepo{}, &qRedisRepo{}, &qService{})
+	if err := gp.BindQualified((*qRepo)(nil), "primary", (*qMySQLRepo)(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gp.Bind((*qRepo)(nil), (*qRedisRepo)(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gp.Bind((*qRepo)(nil), &qService{}); err == nil {
+		t.Fatal("Bind should check the implementation")
+	}
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	s := MustResolve[*qService](gp)
+	if s.Primary.Name() != "mysql" || s.Cache.Name() != "redis" || s.Full.Name() != "mysql" || s.Default.Name() != "redis" {
+		t.Fatalf("unexpected implements %v %v %v %v", s.Primary.Name(), s.Cache.Name(), s.Full.Name(), s.Default.Name())
+	}
+	if MustResolve[qRepo](gp).Name() != "redis" {
+		t.Fatal("Resolve should use the default binding")
+	}
+}
@@ -0,0 +1,111 @@
+package gdi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule 架构规则，正则匹配结构体的 "包路径.类型名"(如 github.com/app/domain.Order)，
+// From 匹配的结构体不能依赖 Deny 匹配的类型，设定 Only 时只能依赖 Only 匹配的类型
+// Example：gdi.Rule{Name: "layering", From: "/domain\\.", Deny: "/infra\\."}，gdi.Rule{From: "Controller$", Only: "Service$"}
+type Rule struct {
+	Name string
+	From string
+	Deny string
+	Only string
+}
+
+// RuleViolation 违反架构规则的注入关系，Path 为属性路径(如 *domain.Order.Repo)
+type RuleViolation struct {
+	Rule      string
+	Type      string // 依赖方的结构体类型
+	Field     string // 属性名
+	DependsOn string // 注入的类型
+	Path      string
+}
+
+func (e *RuleViolation) Error() string {
+	return fmt.Sprintf("rule %v violated: %v -> %v", e.Rule, e.Path, e.DependsOn)
+}
+
+// CheckRules 检查全局容器的依赖关系是否违反架构规则
+func CheckRules(rules ...Rule) error {
+	return globalGDI.CheckRules(rules...)
+}
+
+// CheckRules 检查依赖图中的注入关系是否违反架构规则，返回的 MultiError 中为 *RuleViolation，可用于在单元测试中约束分层
+// Example：if err := pool.CheckRules(gdi.Rule{Name: "domain", From: "/domain\\.", Deny: "/infra\\."}); err != nil { t.Fatal(err) }
+func (gdi *GDIPool) CheckRules(rules ...Rule) error {
+	dg, err := gdi.DependencyGraph()
+	if err != nil {
+		return err
+	}
+	pkgPaths := make(map[string]string) // 没有注入过属性的对象(只读对象、构造函数的返回值等)使用容器中的类型
+	for name, n := range gdi.containerNodes() {
+		pkgPaths[name] = n.PkgPath
+	}
+	for _, n := range dg.Nodes {
+		if n.PkgPath != "" {
+			pkgPaths[n.Name] = n.PkgPath
+		}
+	}
+	var errs MultiError
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%v", i)
+		}
+		from, deny, only, err := r.compile()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("(ERROR) rule %v: %v", name, err))
+			continue
+		}
+		for _, e := range dg.Edges {
+			if from == nil || !from.MatchString(qualifiedTypeName(e.From, pkgPaths)) {
+				continue
+			}
+			to := qualifiedTypeName(e.To, pkgPaths)
+			if deny != nil && deny.MatchString(to) || only != nil && !only.MatchString(to) {
+				errs = append(errs, &RuleViolation{Rule: name, Type: e.From, Field: e.Field, DependsOn: e.To, Path: e.From + "." + e.Field})
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// compile 编译规则中的正则，没有设定的为 nil
+func (r Rule) compile() (from, deny, only *regexp.Regexp, err error) {
+	if r.From == "" {
+		return nil, nil, nil, fmt.Errorf("from is required")
+	}
+	if r.Deny == "" && r.Only == "" {
+		return nil, nil, nil, fmt.Errorf("deny or only is required")
+	}
+	res := make([]*regexp.Regexp, 3)
+	for i, patten := range []string{r.From, r.Deny, r.Only} {
+		if patten == "" {
+			continue
+		}
+		if res[i], err = regexp.Compile(patten); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return res[0], res[1], res[2], nil
+}
+
+// qualifiedTypeName 类型名称 *pkg.T 转换为 包路径.T，不知道包路径时返回原来的名称
+func qualifiedTypeName(name string, pkgPaths map[string]string) string {
+	pkgPath, ok := pkgPaths[name]
+	if !ok || pkgPath == "" {
+		return name
+	}
+	short := strings.TrimLeft(name, "*")
+	if i := strings.Index(short, "."); i >= 0 {
+		short = short[i+1:]
+	}
+	return pkgPath + "." + short
+}
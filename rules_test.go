@@ -0,0 +1,73 @@
+package gdi
+
+import (
+	"errors"
+	"testing"
+)
+
+type ruDB struct{}
+type ruRepo struct{ DB *ruDB }
+type ruOrderService struct{ Repo *ruRepo }
+type ruOrder struct{ DB *ruDB }
+type ruUserController struct {
+	Service *ruOrderService
+	Repo    *ruRepo
+}
+
+func TestCheckRules(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&ruDB{}, &ruRepo{}, &ruOrderService{}, &ruOrder{}, &ruUserController{})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gp.CheckRules(Rule{Name: "repo", From: "\\.ruRepo$", Only: "\\.ruDB$"}); err != nil {
+		t.Fatal(err)
+	}
+	err := gp.CheckRules(
+		Rule{Name: "domain", From: "github.com/sjqzhang/gdi\\.ruOrder$", Deny: "\\.ruDB$"},
+		Rule{Name: "controller", From: "Controller$", Only: "Service$"},
+	)
+	var errs MultiError
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expected 2 violations, got %v", err)
+	}
+	expects := []RuleViolation{
+		{Rule: "domain", Type: "*gdi.ruOrder", Field: "DB", DependsOn: "*gdi.ruDB", Path: "*gdi.ruOrder.DB"},
+		{Rule: "controller", Type: "*gdi.ruUserController", Field: "Repo", DependsOn: "*gdi.ruRepo", Path: "*gdi.ruUserController.Repo"},
+	}
+	for i, e := range errs {
+		var v *RuleViolation
+		if !errors.As(e, &v) || *v != expects[i] {
+			t.Fatalf("unexpected violation %v", e)
+		}
+	}
+	if err := gp.CheckRules(Rule{From: "("}, Rule{From: "x"}); err == nil || len(err.(MultiError)) != 2 {
+		t.Fatalf("invalid rules should fail, got %v", err)
+	}
+}
+
+type ruInfra struct{}
+type ruCtorService struct{ infra *ruInfra }
+
+func TestCheckRulesConstructor(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.RegisterReadOnly(&ruInfra{})
+	gp.Register(func(infra *ruInfra) *ruCtorService {
+		return &ruCtorService{infra: infra}
+	})
+	if err := gp.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	err := gp.CheckRules(Rule{Name: "infra", From: "github.com/sjqzhang/gdi\\.ruCtorService$", Deny: "github.com/sjqzhang/gdi\\.ruInfra$"})
+	var errs MultiError
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected 1 violation, got %v", err)
+	}
+	var v *RuleViolation
+	expect := RuleViolation{Rule: "infra", Type: "*gdi.ruCtorService", Field: "arg#0", DependsOn: "*gdi.ruInfra", Path: "*gdi.ruCtorService.arg#0"}
+	if !errors.As(errs[0], &v) || *v != expect {
+		t.Fatalf("unexpected violation %v", errs[0])
+	}
+}
@@ -0,0 +1,425 @@
+package gdi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const scopePrototype = "prototype"
+
+var errScopeClosed = errors.New("(ERROR) scope is closed")
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// RegisterPrototype 注册原型(多例)对象，每次注入或获取都会创建新的实例
+func RegisterPrototype(funcObjOrPtrs ...interface{}) {
+	globalGDI.RegisterPrototype(funcObjOrPtrs...)
+}
+
+// RegisterPrototype 注册原型(多例)对象，每次注入或获取都会创建新的实例
+// 支持结构体指针(作为模板浅拷贝)或返回对象指针的函数(函数参数从容器中注入)
+func (gdi *GDIPool) RegisterPrototype(funcObjOrPtrs ...interface{}) {
+	for i := range funcObjOrPtrs {
+		funcObjOrPtr := funcObjOrPtrs[i]
+		ftype := reflect.TypeOf(funcObjOrPtr)
+		if ftype.Kind() != reflect.Ptr && ftype.Kind() != reflect.Func {
+			gdi.warn(fmt.Sprintf("(WARNNING) register prototype %v fail just support a struct pointer or a function return a struct pointer ", ftype))
+			return
+		}
+		outType := ftype
+		if ftype.Kind() == reflect.Func {
+			var err error
+			if outType, err = gdi.parsePoolFunc(funcObjOrPtr); err != nil {
+				gdi.panic(err.Error())
+			}
+		} else if ftype.Elem().Kind() != reflect.Struct {
+			gdi.panic(fmt.Sprintf("%v type not support ", ftype))
+		}
+		if _, ok := gdi.get(outType); ok {
+			gdi.panic(fmt.Sprintf("double register %v", outType))
+		}
+		gdi.creatorLocker.Lock()
+		_, isPrototype := gdi.prototypes[outType]
+		_, isCreator := gdi.creator[outType]
+		_, isScoped := gdi.scoped[outType]
+		if isPrototype || isCreator || isScoped { // 同一类型不能既是原型又是单例或作用域对象
+			gdi.creatorLocker.Unlock()
+			gdi.panic(fmt.Sprintf("double register %v", outType))
+		}
+		gdi.prototypes[outType] = funcObjOrPtr
+		gdi.creatorLocker.Unlock()
+		gdi.log(fmt.Sprintf("register prototype, type:%v success", outType), "event", eventRegister, "type", outType.String(), "pkgPath", outType.Elem().PkgPath())
+	}
+}
+
+func (gdi *GDIPool) isPrototype(t reflect.Type) bool {
+	gdi.creatorLocker.RLock()
+	defer gdi.creatorLocker.RUnlock()
+	_, ok := gdi.prototypes[t]
+	return ok
+}
+
+func (gdi *GDIPool) getPrototypeByInterface(i reflect.Type) (reflect.Type, bool) {
+	gdi.creatorLocker.RLock()
+	defer gdi.creatorLocker.RUnlock()
+	var found []reflect.Type
+	for t := range gdi.prototypes {
+		if t.Implements(i) {
+			found = append(found, t)
+		}
+	}
+	if len(found) != 1 {
+		return nil, false
+	}
+	return found[0], true
+}
+
+// resolve 按类型获取对象，原型对象每次都会新建
+func (gdi *GDIPool) resolve(t reflect.Type) (reflect.Value, bool) {
+	if gdi.isClosed() {
+		return reflect.Value{}, false
+	}
+	if gdi.isPrototype(t) {
+		v, err := gdi.newInstance(t)
+		if err != nil {
+			gdi.error(err.Error())
+			return reflect.Value{}, false
+		}
+		return v, true
+	}
+	return gdi.get(t)
+}
+
+// newInstance 创建一个新的实例并完成属性注入，优先使用原型工厂，其次是构造函数，最后直接new，
+// 原型之间(包括自身)循环注入时返回 CycleError
+func (gdi *GDIPool) newInstance(t reflect.Type) (reflect.Value, error) {
+	if path := gdi.prototypeCycle(t); len(path) > 0 {
+		return reflect.Value{}, &CycleError{Path: path}
+	}
+	gdi.creatorLocker.RLock()
+	factory, ok := gdi.prototypes[t]
+	if !ok {
+		factory, ok = gdi.creator[t]
+	}
+	gdi.creatorLocker.RUnlock()
+	var value reflect.Value
+	if !ok {
+		if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("can't create a new instance of %v", t)
+		}
+		value = reflect.New(t.Elem())
+	} else if reflect.TypeOf(factory).Kind() == reflect.Ptr {
+		value = reflect.New(t.Elem())
+		value.Elem().Set(reflect.ValueOf(factory).Elem())
+	} else {
+		v, err := gdi.callFactory(factory)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value = v
+	}
+	gdi.build(value, false, false)
+	return value, nil
+}
+
+// prototypeCycle 检查创建 t 时需要新建的原型(属性及构造函数参数)是否又回到 t，返回循环的路径
+func (gdi *GDIPool) prototypeCycle(start reflect.Type) []string {
+	visiting := make(map[reflect.Type]bool)
+	var visit func(t reflect.Type, path []string) []string
+	visit = func(t reflect.Type, path []string) []string {
+		if visiting[t] {
+			if t == start {
+				return append(path, t.String())
+			}
+			return nil
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		for _, d := range gdi.prototypeDeps(t) {
+			if cycle := visit(d.typ, append(path, fmt.Sprintf("%v.%v", t, d.field))); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit(start, nil)
+}
+
+// prototypeDep 创建原型时需要新建的依赖
+type prototypeDep struct {
+	field string
+	typ   reflect.Type
+}
+
+// prototypeDeps 创建 t 的新实例时需要新建的原型：构造函数中的原型参数，以及 build 时会注入新实例的属性
+func (gdi *GDIPool) prototypeDeps(t reflect.Type) []prototypeDep {
+	gdi.creatorLocker.RLock()
+	factory, ok := gdi.prototypes[t]
+	if !ok {
+		factory, ok = gdi.creator[t]
+	}
+	gdi.creatorLocker.RUnlock()
+	var deps []prototypeDep
+	var template reflect.Value
+	if ok && reflect.TypeOf(factory).Kind() == reflect.Func {
+		funcType := reflect.TypeOf(factory)
+		for n := 0; n < funcType.NumIn(); n++ {
+			if gdi.isPrototype(funcType.In(n)) {
+				deps = append(deps, prototypeDep{field: fmt.Sprintf("%v%v", creatorArgField, n), typ: funcType.In(n)})
+			}
+		}
+		t = funcType.Out(0)
+	} else if ok {
+		template = reflect.ValueOf(factory).Elem()
+	}
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return deps
+	}
+	for i := 0; i < t.Elem().NumField(); i++ {
+		f := t.Elem().Field(i)
+		if f.Type.Kind() != reflect.Ptr && f.Type.Kind() != reflect.Interface || gdi.getInjectOption(f) == injectSkip {
+			continue
+		}
+		if name, ok := gdi.getTagAttr(f, "name"); ok && name != "" {
+			continue
+		}
+		if f.PkgPath != "" && gdi.ignorePrivate || template.IsValid() && !template.Field(i).IsNil() {
+			continue
+		}
+		if pt, ok := gdi.prototypeTypeOf(f.Type, f); ok {
+			deps = append(deps, prototypeDep{field: f.Name, typ: pt})
+		}
+	}
+	return deps
+}
+
+// callFactory 调用构造函数，参数从容器中获取
+func (gdi *GDIPool) callFactory(factory interface{}) (reflect.Value, error) {
+	funcType := reflect.TypeOf(factory)
+	var args []reflect.Value
+	for n := 0; n < funcType.NumIn(); n++ {
+		arg, ok := gdi.lazyArg(funcType.In(n), factory)
+		if !ok {
+			arg, ok = gdi.resolve(funcType.In(n))
+		}
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("(ERROR)create %v fail, parameter type %v not found", funcType, funcType.In(n))
+		}
+		args = append(args, arg)
+	}
+	start := time.Now()
+	values := reflect.ValueOf(factory).Call(args)
+	if len(values) > 1 && values[1].Type().Implements(reflect.TypeOf((*error)(nil)).Elem()) && !values[1].IsNil() {
+		err := fmt.Errorf("(ERROR)create %v fail %v", funcType, values[1].Interface())
+		gdi.emitCreate(CreateEvent{Type: funcType.Out(0), Creator: funcType, Duration: time.Since(start), Err: err})
+		return reflect.Value{}, err
+	}
+	gdi.emitCreate(CreateEvent{Type: funcType.Out(0), Creator: funcType, Duration: time.Since(start)})
+	return values[0], nil
+}
+
+// prototypeTypeOf 判断属性是否需要注入新实例(已注册为原型或标记了 inject:"scope:prototype")
+func (gdi *GDIPool) prototypeTypeOf(fieldType reflect.Type, f reflect.StructField) (reflect.Type, bool) {
+	if fieldType.Kind() == reflect.Interface {
+		return gdi.getPrototypeByInterface(fieldType)
+	}
+	if gdi.isPrototype(fieldType) {
+		return fieldType, true
+	}
+	if scope, _ := gdi.getTagAttr(f, "scope"); scope == scopePrototype && fieldType.Elem().Kind() == reflect.Struct {
+		return fieldType, true
+	}
+	return nil, false
+}
+
+// RegisterScoped 注册作用域对象，对象在每个 NewScope 创建的子容器中只创建一次，子容器关闭后丢弃
+func RegisterScoped(funcObjOrPtrs ...interface{}) {
+	globalGDI.RegisterScoped(funcObjOrPtrs...)
+}
+
+// NewScope 创建子容器，继承全局容器的所有单例
+func NewScope(ctx context.Context) *GDIPool {
+	return globalGDI.NewScope(ctx)
+}
+
+// RegisterScoped 注册作用域对象，对象在每个 NewScope 创建的子容器中只创建一次，子容器关闭后丢弃
+// 支持结构体指针(作为模板浅拷贝)或返回对象指针的函数(函数参数从子容器中注入，可以使用 context.Context 参数)
+func (gdi *GDIPool) RegisterScoped(funcObjOrPtrs ...interface{}) {
+	for i := range funcObjOrPtrs {
+		funcObjOrPtr := funcObjOrPtrs[i]
+		ftype := reflect.TypeOf(funcObjOrPtr)
+		if ftype.Kind() != reflect.Ptr && ftype.Kind() != reflect.Func {
+			gdi.warn(fmt.Sprintf("(WARNNING) register scoped %v fail just support a struct pointer or a function return a struct pointer ", ftype))
+			return
+		}
+		outType := ftype
+		if ftype.Kind() == reflect.Func {
+			var err error
+			if outType, err = gdi.parsePoolFunc(funcObjOrPtr); err != nil {
+				gdi.panic(err.Error())
+			}
+		} else if ftype.Elem().Kind() != reflect.Struct {
+			gdi.panic(fmt.Sprintf("%v type not support ", ftype))
+		}
+		if _, ok := gdi.get(outType); ok || gdi.isPrototype(outType) {
+			gdi.panic(fmt.Sprintf("double register %v", outType))
+		}
+		gdi.creatorLocker.Lock()
+		if _, ok := gdi.scoped[outType]; ok {
+			gdi.creatorLocker.Unlock()
+			gdi.panic(fmt.Sprintf("double register %v", outType))
+		}
+		gdi.scoped[outType] = funcObjOrPtr
+		gdi.creatorLocker.Unlock()
+		gdi.log(fmt.Sprintf("register scoped, type:%v success", outType), "event", eventRegister, "type", outType.String(), "pkgPath", outType.Elem().PkgPath())
+	}
+}
+
+// NewScope 创建子容器，子容器继承当前容器的所有单例，同时持有自己的作用域对象(如：请求会话、数据库事务、请求日志)
+// 子容器中可以通过 context.Context 类型获取到 ctx，使用完后需要调用 Close 释放
+func (gdi *GDIPool) NewScope(ctx context.Context) *GDIPool {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	gdi.creatorLocker.RLock()
+	profiles := append([]string{}, gdi.profiles...)
+	gdi.creatorLocker.RUnlock()
+	scope := &GDIPool{
+		lock:                  sync.Mutex{},
+		debug:                 gdi.debug,
+		logger:                gdi.logger,
+		logLevel:              gdi.logLevel,
+		noColor:               gdi.noColor,
+		scanPkgPaths:          gdi.scanPkgPaths,
+		ignoreInterface:       gdi.ignoreInterface,
+		autoCreate:            gdi.autoCreate,
+		ignorePrivate:         gdi.ignorePrivate,
+		strict:                gdi.strict,
+		profiles:              profiles,
+		creator:               gdi.creator,
+		invoked:               make(map[reflect.Type]bool),
+		prototypes:            gdi.prototypes,
+		scoped:                gdi.scoped,
+		creatorLocker:         sync.RWMutex{},
+		allTypesToValues:      gdi.allTypesToValues,
+		typeToValuesForTest:   make(map[reflect.Type]reflect.Value),
+		typeToValues:          make(map[reflect.Type]reflect.Value),
+		typeToValuesReadOnly:  make(map[reflect.Type]reflect.Value),
+		namesToValues:         make(map[string]reflect.Value),
+		namesToValuesReadOnly: make(map[string]reflect.Value),
+		interfaceToImplements: gdi.interfaceToImplements,
+		qualifiers:            gdi.qualifiers,
+		config:                make(map[string]string),
+		ttvLocker:             sync.RWMutex{},
+		g:                     gdi.g,
+		lc:                    newLifecycle(),
+		fs:                    gdi.fs,
+		placeHolders:          gdi.placeHolders,
+		src:                   gdi.src,
+		allowedCycles:         gdi.allowedCycles,
+		decorated:             make(map[reflect.Type]reflect.Value),
+		decoratedIfaces:       make(map[reflect.Type]*decoratedIface),
+		provenance:            make(map[interface{}]Provenance),
+		consoleLog:            gdi.consoleLog,
+		graphExporters:        gdi.copyGraphExporters(),
+		parent:                gdi,
+		ctx:                   ctx,
+	}
+	scope.typeToValuesReadOnly[contextType] = reflect.ValueOf(&scope.ctx).Elem()
+	return scope
+}
+
+// Context 获取子容器的上下文
+func (gdi *GDIPool) Context() context.Context {
+	if gdi.ctx == nil {
+		return context.Background()
+	}
+	return gdi.ctx
+}
+
+// Close 关闭子容器，按依赖逆序调用作用域对象的 Close 方法后丢弃子容器中的所有作用域对象，
+// 关闭后的子容器不能再获取或注入对象
+func (gdi *GDIPool) Close() error {
+	if gdi.parent == nil {
+		return fmt.Errorf("(ERROR) only scope created by NewScope can be closed")
+	}
+	if gdi.isClosed() {
+		return nil
+	}
+	err := gdi.Shutdown(context.Background())
+	gdi.ttvLocker.Lock()
+	defer gdi.ttvLocker.Unlock()
+	gdi.closed = true
+	gdi.typeToValues = make(map[reflect.Type]reflect.Value)
+	gdi.typeToValuesReadOnly = make(map[reflect.Type]reflect.Value)
+	gdi.typeToValuesForTest = make(map[reflect.Type]reflect.Value)
+	gdi.namesToValues = make(map[string]reflect.Value)
+	gdi.namesToValuesReadOnly = make(map[string]reflect.Value)
+	gdi.typeToValuesReadOnly[contextType] = reflect.ValueOf(&gdi.ctx).Elem()
+	gdi.lc = newLifecycle()
+	return err
+}
+
+func (gdi *GDIPool) isClosed() bool {
+	gdi.ttvLocker.RLock()
+	defer gdi.ttvLocker.RUnlock()
+	return gdi.closed
+}
+
+// getFromParent 子容器中找不到对象时，先创建作用域对象，否则从父容器中获取
+func (gdi *GDIPool) getFromParent(t reflect.Type) (reflect.Value, bool) {
+	gdi.creatorLocker.RLock()
+	factory, ok := gdi.scoped[t]
+	gdi.creatorLocker.RUnlock()
+	if !ok {
+		return gdi.parent.get(t)
+	}
+	var value reflect.Value
+	if reflect.TypeOf(factory).Kind() == reflect.Ptr {
+		value = reflect.New(t.Elem())
+		value.Elem().Set(reflect.ValueOf(factory).Elem())
+	} else {
+		v, err := gdi.callFactory(factory)
+		if err != nil {
+			gdi.error(err.Error())
+			return reflect.Value{}, false
+		}
+		value = v
+	}
+	gdi.ttvLocker.Lock()
+	if exist, ok := gdi.typeToValues[t]; ok {
+		gdi.ttvLocker.Unlock()
+		return exist, true
+	}
+	gdi.typeToValues[t] = value
+	gdi.ttvLocker.Unlock()
+	gdi.log(fmt.Sprintf("create scoped type:%v success", t))
+	gdi.build(value, false, false)
+	if err := gdi.afterInject(); err != nil {
+		gdi.error(err.Error())
+		return reflect.Value{}, false
+	}
+	return value, true
+}
+
+func (gdi *GDIPool) getScopedByInterface(i reflect.Type) (reflect.Type, bool) {
+	if gdi.parent == nil {
+		return nil, false
+	}
+	gdi.creatorLocker.RLock()
+	defer gdi.creatorLocker.RUnlock()
+	var found []reflect.Type
+	for t := range gdi.scoped {
+		if t.Implements(i) {
+			found = append(found, t)
+		}
+	}
+	if len(found) != 1 {
+		return nil, false
+	}
+	return found[0], true
+}
@@ -0,0 +1,187 @@
+package gdi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type protoRequest struct {
+	ID int
+}
+
+type protoHandler struct {
+	Req *protoRequest
+}
+
+type protoConfig struct {
+	Name string
+}
+
+type protoTagged struct {
+	C1 *protoConfig `inject:"scope:prototype"`
+	C2 *protoConfig
+}
+
+func TestPrototype(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	seq := 0
+	gp.RegisterPrototype(func() *protoRequest {
+		seq++
+		return &protoRequest{ID: seq}
+	})
+	gp.RegisterPrototype(&protoHandler{})
+	gp.Register(&protoConfig{Name: "singleton"})
+	gp.Init()
+
+	var h *protoHandler
+	h1 := gp.Get(h).(*protoHandler)
+	h2 := gp.Get(h).(*protoHandler)
+	if h1 == h2 || h1.Req == h2.Req {
+		t.Fatal("prototype should create a new instance every time")
+	}
+	if h1.Req.ID == h2.Req.ID {
+		t.Fatal("prototype constructor should be called for every injection")
+	}
+
+	var tagged protoTagged
+	gp.DI(&tagged)
+	var c *protoConfig
+	if tagged.C2 != gp.Get(c).(*protoConfig) {
+		t.Fatal("field without scope tag should be singleton")
+	}
+	if tagged.C1 == tagged.C2 || tagged.C1 == nil {
+		t.Fatal("field tagged scope:prototype should get a new instance")
+	}
+}
+
+type protoSelf struct {
+	Self *protoSelf
+}
+
+type protoPing struct {
+	Pong *protoPong
+}
+
+type protoPong struct {
+	Ping *protoPing
+}
+
+type protoOwner struct {
+	Ping *protoPing
+}
+
+type protoSingleton struct{}
+
+func TestPrototypeCycle(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.RegisterPrototype(&protoSelf{}, &protoPing{}, func(ping *protoPing) *protoPong {
+		return &protoPong{Ping: ping}
+	})
+	gp.Register(&protoOwner{})
+	err := gp.InitE()
+	var ce *CycleError
+	if !errors.As(err, &ce) || strings.Join(ce.Path, " -> ") != "*gdi.protoPing.Pong -> *gdi.protoPong.arg#0 -> *gdi.protoPing" {
+		t.Fatalf("expect prototype cycle error, got %v", err)
+	}
+	if _, err := Resolve[*protoSelf](gp); !errors.As(err, &ce) || strings.Join(ce.Path, " -> ") != "*gdi.protoSelf.Self -> *gdi.protoSelf" {
+		t.Fatalf("expect self cycle error, got %v", err)
+	}
+
+	gp = NewGDIPool()
+	gp.Debug(false)
+	gp.Register(func(c *protoConfig) *protoSingleton { return &protoSingleton{} })
+	gp.RegisterScoped(&protoRequest{})
+	for _, proto := range []interface{}{&protoSingleton{}, &protoRequest{}} {
+		func() {
+			defer func() {
+				if err := recover(); err == nil || !strings.Contains(fmt.Sprint(err), "double register") {
+					t.Fatalf("%T registered as singleton or scoped should not be a prototype, got %v", proto, err)
+				}
+			}()
+			gp.RegisterPrototype(proto)
+		}()
+	}
+}
+
+type scopeSession struct {
+	User string
+}
+
+type scopeService struct {
+	Name string
+}
+
+type scopeController struct {
+	Session *scopeSession
+	Service *scopeService
+}
+
+func TestNewScope(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Register(&scopeService{Name: "svc"})
+	gp.RegisterScoped(func(ctx context.Context) *scopeSession {
+		return &scopeSession{User: ctx.Value("user").(string)}
+	})
+	gp.Init()
+
+	s1 := gp.NewScope(context.WithValue(context.Background(), "user", "u1"))
+	s2 := gp.NewScope(context.WithValue(context.Background(), "user", "u2"))
+	var c1, c2 scopeController
+	if err := s1.DI(&c1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.DI(&c2); err != nil {
+		t.Fatal(err)
+	}
+	if c1.Session.User != "u1" || c2.Session.User != "u2" {
+		t.Fatal("scoped object should be created per scope")
+	}
+	if c1.Service != c2.Service || c1.Service.Name != "svc" {
+		t.Fatal("singleton should be inherited from parent")
+	}
+	var session *scopeSession
+	if s1.Get(session).(*scopeSession) != c1.Session {
+		t.Fatal("scoped object should be cached in scope")
+	}
+	s1.Close()
+	if s1.Get(session) != nil {
+		t.Fatal("scoped object should be dropped after close")
+	}
+	if _, err := Resolve[*scopeService](s1); !errors.Is(err, errScopeClosed) {
+		t.Fatalf("expect scope closed error, got %v", err)
+	}
+	if err := s1.DI(&c1); !errors.Is(err, errScopeClosed) {
+		t.Fatalf("expect scope closed error on DI, got %v", err)
+	}
+	if err := s1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s2.Get(session).(*scopeSession) != c2.Session {
+		t.Fatal("closing a scope should not affect other scopes")
+	}
+}
+
+func TestNewScopeInheritSettings(t *testing.T) {
+	gp := NewGDIPool()
+	gp.Debug(false)
+	gp.Strict(true)
+	gp.AllowCycle((*cycEE)(nil), "A")
+	gp.SetProfiles("prod")
+	s := gp.NewScope(context.Background())
+	defer s.Close()
+	if !s.strict {
+		t.Fatal("scope should inherit strict mode")
+	}
+	if !s.IsProfileActive("prod") || s.IsProfileActive("dev") {
+		t.Fatalf("scope should inherit profiles, got %v", s.ActiveProfiles())
+	}
+	if !s.allowedCycles["*gdi.cycEE.A"] {
+		t.Fatal("scope should inherit allowed cycles")
+	}
+}